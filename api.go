@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+// The JSON API shares its kits with the web pages. All responses
+// are JSON objects, errors have the form {"error": "message"}.
+//
+//	GET    /kits                    lists all kits
+//	POST   /kits                    uploads a matches file
+//	GET    /kits/{id}               returns the report of a kit
+//	DELETE /kits/{id}               removes a kit
+//	GET    /kits/{id}/frequencies   returns the frequencies of one type
+//	GET    /kits/{id}/crosstab      returns the surnames per location
+//	POST   /compare                 unites or intersects several kits
+//
+// Reports, frequencies and crosstabs accept the parameters
+// cluster, exclude and min.
+// If the server is started with -testers, reports contain the
+// cousins per million testers of the countries.
+//
+// Request bodies may not be larger than maxUploadSize. If the server
+// is started with -token, requests must contain the header
+// "Authorization: Bearer <token>".
+
+// kitInfo describes a kit in API responses.
+type kitInfo struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Cousins int    `json:"cousins"`
+}
+
+func (k *kit) info() kitInfo {
+	return kitInfo{ID: k.ID, Name: k.Name, Cousins: len(k.ancestries)}
+}
+
+// compareRequest is the body of a POST /compare request.
+type compareRequest struct {
+	// Kits are the IDs of the kits to compare.
+	Kits []string `json:"kits"`
+	// Operation is "unite" or "intersect".
+	Operation string `json:"operation"`
+	// By is the -by option of the intersect command.
+	By      string `json:"by"`
+	Cluster string `json:"cluster"`
+	Exclude string `json:"exclude"`
+	Min     int    `json:"min"`
+}
+
+// isAPIPath reports whether path belongs to the JSON API.
+func isAPIPath(path string) bool {
+	return path == "/kits" || strings.HasPrefix(path, "/kits/") || path == "/compare"
+}
+
+// registerAPI registers the handlers of the JSON API.
+func (s *server) registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("/kits", s.handleAPIKits)
+	mux.HandleFunc("/kits/", s.handleAPIKit)
+	mux.HandleFunc("/compare", s.handleAPICompare)
+}
+
+// removeKit removes the kit with the given ID and reports
+// whether it existed.
+func (s *server) removeKit(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.kits[id]
+	delete(s.kits, id)
+	return ok
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (s *server) handleAPIKits(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		infos := []kitInfo{}
+		for _, k := range s.kitList() {
+			infos = append(infos, k.info())
+		}
+		writeJSON(w, http.StatusOK, infos)
+	case http.MethodPost:
+		name, data, err := uploadedFile(r)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ancestries, err := s.opts.parseAncestries(data)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "error reading matches file: "+err.Error())
+			return
+		}
+		k := s.addKit(name, ancestries)
+		w.Header().Set("Location", "/kits/"+k.ID)
+		writeJSON(w, http.StatusCreated, k.info())
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// uploadedFile returns the name and content of a matches file.
+// The file is either sent as multipart form field "file"
+// or directly as request body. In the latter case the
+// name is taken from the query parameter "name".
+func uploadedFile(r *http.Request) (name string, data []byte, err error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return "", nil, fmt.Errorf("no file uploaded or file larger than %d MB", maxUploadSize>>20)
+		}
+		defer file.Close()
+		data, err = ioutil.ReadAll(file)
+		return header.Filename, data, err
+	}
+	// The size of the body is limited by limitBody.
+	data, err = ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading file, the maximum size is %d MB", maxUploadSize>>20)
+	}
+	if len(data) == 0 {
+		return "", nil, errors.New("no file uploaded")
+	}
+	name = r.URL.Query().Get("name")
+	if name == "" {
+		name = "upload.csv"
+	}
+	return name, data, nil
+}
+
+func (s *server) handleAPIKit(w http.ResponseWriter, r *http.Request) {
+	// Path is /kits/{id}, /kits/{id}/frequencies or /kits/{id}/crosstab.
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/kits/"), "/")
+	k := s.kit(parts[0])
+	if k == nil || len(parts) > 2 {
+		writeJSONError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			f := filtersOf(r)
+			writeJSON(w, http.StatusOK, newReport(f.analysis(k), f.Min, s.opts.population))
+		case http.MethodDelete:
+			s.removeKit(k.ID)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+	if parts[1] != "frequencies" && parts[1] != "crosstab" {
+		writeJSONError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	f := filtersOf(r)
+	a := f.analysis(k)
+	if parts[1] == "crosstab" {
+		writeJSON(w, http.StatusOK, newCrosstab(a, f.Min))
+		return
+	}
+	freqs, ok := a.frequenciesOfType(r.FormValue("type"))
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "type must be countries, locations, surnames or heatmap")
+		return
+	}
+	writeJSON(w, http.StatusOK, atLeast(freqs, f.Min))
+}
+
+func (s *server) handleAPICompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req compareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	if len(req.Kits) < 2 {
+		writeJSONError(w, http.StatusBadRequest, "at least two kits needed")
+		return
+	}
+	if req.By == "" {
+		req.By = "lines"
+	}
+	if req.Operation == "intersect" && !validIntersection(req.By) {
+		writeJSONError(w, http.StatusBadRequest, "by must be lines, names, locations or names,locations")
+		return
+	}
+	elements := make([]cousins.Ancestries, 0, len(req.Kits))
+	for _, id := range req.Kits {
+		k := s.kit(id)
+		if k == nil {
+			writeJSONError(w, http.StatusNotFound, "kit "+id+" not found")
+			return
+		}
+		elements = append(elements, k.ancestries)
+	}
+	list := cousins.NewAncestriesListOf(elements...)
+
+	var a *analysis
+	switch req.Operation {
+	case "unite":
+		a = newAnalysis(list.Unite())
+	case "intersect":
+		a = intersection(&list, req.By)
+	default:
+		writeJSONError(w, http.StatusBadRequest, "operation must be unite or intersect")
+		return
+	}
+	a.filter(&options{cluster: req.Cluster, exclude: req.Exclude})
+	min := req.Min
+	if min < 1 {
+		min = 1
+	}
+	writeJSON(w, http.StatusOK, newReport(a, min, s.opts.population))
+}
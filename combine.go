@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var uniteCommand = &command{
+	name:  "unite",
+	usage: "[options] <matches file> <matches file>...",
+	short: "Merges several matches files and analyses them together.",
+	long: "Unite merges the matches of several files, for example the files of your parents.\r\n" +
+		"Cousins who occur in several files are counted only once.",
+	run: runUnite,
+}
+
+var intersectCommand = &command{
+	name:  "intersect",
+	usage: "[options] <matches file> <matches file>...",
+	short: "Analyses the ancestral information common to several matches files.",
+	long: "Intersect evaluates only ancestral information that is common to all input files.\r\n" +
+		"The option -by selects what must be common:\r\n" +
+		"  lines            identical ancestral information\r\n" +
+		"  names            common surnames\r\n" +
+		"  locations        common locations\r\n" +
+		"  names,locations  common surnames and common locations",
+	run: runIntersect,
+}
+
+func runUnite(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	opts.registerReport(fs)
+	csvout := fs.String("csvout", "", "Writes countries and frequencies of cousins to a file in CSV format.")
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	if len(files) < 2 {
+		return errors.New("unite needs at least two input files")
+	}
+	if err := checkOutputFile(*csvout, files); err != nil {
+		return err
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+
+	ancestriesList, err := opts.loadList(files)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Uniting files %v.\r\n\r\n", strings.Join(files, ","))
+	return newAnalysis(ancestriesList.Unite()).run(&opts, *csvout)
+}
+
+func runIntersect(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	opts.registerReport(fs)
+	csvout := fs.String("csvout", "", "Writes countries and frequencies of cousins to a file in CSV format.")
+	by := fs.String("by", "lines", "Intersects by identical lines, names, locations or names,locations.")
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	if len(files) < 2 {
+		return errors.New("intersect needs at least two input files")
+	}
+	if err := checkOutputFile(*csvout, files); err != nil {
+		return err
+	}
+	if !validIntersection(*by) {
+		return fmt.Errorf("invalid value %q for -by, must be lines, names, locations or names,locations", *by)
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+
+	ancestriesList, err := opts.loadList(files)
+	if err != nil {
+		return err
+	}
+	filenames := strings.Join(files, ",")
+	switch *by {
+	case "lines":
+		fmt.Printf("Intersecting files %v, looking for identical ancestral information.\r\n\r\n", filenames)
+	case "names":
+		fmt.Printf("Intersecting files %v, looking for common names.\r\n\r\n", filenames)
+	case "locations":
+		fmt.Printf("Intersecting files %v, looking for common locations.\r\n\r\n", filenames)
+	default:
+		fmt.Printf("Intersecting files %v, looking for common names and locations.\r\n\r\n", filenames)
+	}
+	return intersection(&ancestriesList, *by).run(&opts, *csvout)
+}
+
+// validIntersection reports whether by is a valid value of the -by option.
+func validIntersection(by string) bool {
+	switch by {
+	case "lines", "names", "locations", "names,locations", "locations,names":
+		return true
+	}
+	return false
+}
+
+// intersection creates an analysis of the ancestral information
+// common to all elements of list. by selects what must be common,
+// see the -by option of the intersect command.
+func intersection(list *cousins.AncestriesList, by string) *analysis {
+	var a *analysis
+	switch by {
+	case "lines":
+		a = newAnalysis(list.Intersect())
+		a.names = list.CommonNames()
+		a.locations = list.CommonLocations()
+		a.restrictCountries()
+	case "names":
+		a = newAnalysis(list.IntersectByNames())
+		a.names = list.CommonNames()
+	case "locations":
+		a = newAnalysis(list.IntersectByLocations())
+		a.locations = list.CommonLocations()
+		a.restrictCountries()
+	default:
+		a = newAnalysis(list.IntersectByNamesAndLocations())
+		a.names = list.CommonNames()
+		a.locations = list.CommonLocations()
+		a.restrictCountries()
+	}
+	return a
+}
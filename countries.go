@@ -172,6 +172,7 @@ var predefinedCountries = []string{
 	"Uzbekistan",
 	"Venezuela",
 	"Vietnam",
+	"Wales",
 	"Yemen",
 	"Zambia",
 	"Zimbabwe",
@@ -230,3 +231,224 @@ var usStates = []string{
 	"west virginia",
 	"wyoming",
 }
+
+// coordinates contains the approximate latitude and longitude of the
+// predefined countries and US states. It is used to draw maps.
+var coordinates = map[string][2]float64{
+	"Afghanistan":              {33.9, 67.7},
+	"Alaska":                   {64.2, -149.5},
+	"Albania":                  {41.2, 20.2},
+	"Algeria":                  {28.0, 1.7},
+	"Angola":                   {-11.2, 17.9},
+	"Andorra":                  {42.5, 1.6},
+	"Armenia":                  {40.1, 45.0},
+	"Argentina":                {-38.4, -63.6},
+	"Aruba":                    {12.5, -70.0},
+	"Australia":                {-25.3, 133.8},
+	"Austria":                  {47.5, 14.6},
+	"Azerbaijan":               {40.1, 47.6},
+	"Bahamas":                  {25.0, -77.4},
+	"Bahrain":                  {26.0, 50.6},
+	"Belarus":                  {53.7, 28.0},
+	"Belgium":                  {50.5, 4.5},
+	"Belize":                   {17.2, -88.5},
+	"Benin":                    {9.3, 2.3},
+	"Bosnia":                   {43.9, 17.7},
+	"Botswana":                 {-22.3, 24.7},
+	"Brazil":                   {-14.2, -51.9},
+	"Bulgaria":                 {42.7, 25.5},
+	"Burkina Faso":             {12.2, -1.6},
+	"Burma":                    {21.9, 95.9},
+	"Burundi":                  {-3.4, 29.9},
+	"Cambodia":                 {12.6, 104.9},
+	"Cameroon":                 {7.4, 12.4},
+	"Canada":                   {56.1, -106.3},
+	"Central African Republic": {6.6, 20.9},
+	"Chad":                     {15.5, 18.7},
+	"Chile":                    {-35.7, -71.5},
+	"China":                    {35.9, 104.2},
+	"Colombia":                 {4.6, -74.3},
+	"Congo":                    {-0.2, 15.8},
+	"Costa Rica":               {9.7, -83.8},
+	"Croatia":                  {45.1, 15.2},
+	"Cyprus":                   {35.1, 33.4},
+	"Czech":                    {49.8, 15.5},
+	"Denmark":                  {56.3, 9.5},
+	"Ecuador":                  {-1.8, -78.2},
+	"Egypt":                    {26.8, 30.8},
+	"El Salvador":              {13.8, -88.9},
+	"England":                  {52.4, -1.2},
+	"Equatorial Guinea":        {1.7, 10.3},
+	"Eritrea":                  {15.2, 39.8},
+	"Estonia":                  {58.6, 25.0},
+	"Ethiopia":                 {9.1, 40.5},
+	"Finland":                  {61.9, 25.7},
+	"France":                   {46.2, 2.2},
+	"Gabon":                    {-0.8, 11.6},
+	"Gambia":                   {13.4, -15.3},
+	"Georgia":                  {42.3, 43.4},
+	"Germany":                  {51.2, 10.5},
+	"Ghana":                    {7.9, -1.0},
+	"Greece":                   {39.1, 21.8},
+	"Greenland":                {71.7, -42.6},
+	"Guinea":                   {9.9, -9.7},
+	"Guinea-Bissau":            {11.8, -15.2},
+	"Guyana":                   {4.9, -58.9},
+	"Guatemala":                {15.8, -90.2},
+	"Cuba":                     {21.5, -77.8},
+	"Djibouti":                 {11.8, 42.6},
+	"Haiti":                    {19.0, -72.3},
+	"Hawaii":                   {19.9, -155.6},
+	"Honduras":                 {15.2, -86.2},
+	"Hong Kong":                {22.3, 114.2},
+	"Hungary":                  {47.2, 19.5},
+	"Iceland":                  {64.9, -19.0},
+	"India":                    {20.6, 79.0},
+	"Iran":                     {32.4, 53.7},
+	"Iraq":                     {33.2, 43.7},
+	"Ireland":                  {53.4, -8.2},
+	"Israel":                   {31.0, 34.9},
+	"Italy":                    {41.9, 12.6},
+	"Ivory Coast":              {7.5, -5.5},
+	"Jamaica":                  {18.1, -77.3},
+	"Japan":                    {36.2, 138.3},
+	"Jordan":                   {30.6, 36.2},
+	"Kazakhstan":               {48.0, 66.9},
+	"Kenya":                    {-0.0, 37.9},
+	"Korea":                    {35.9, 127.8},
+	"Kuwait":                   {29.3, 47.5},
+	"Kyrgyzstan":               {41.2, 74.8},
+	"Laos":                     {19.9, 102.5},
+	"Latvia":                   {56.9, 24.6},
+	"Lebanon":                  {33.9, 35.9},
+	"Liberia":                  {6.4, -9.4},
+	"Lithuania":                {55.2, 23.9},
+	"Libya":                    {26.3, 17.2},
+	"Liechtenstein":            {47.2, 9.6},
+	"Luxembourg":               {49.8, 6.1},
+	"Macedonia":                {41.6, 21.7},
+	"Madagascar":               {-18.8, 46.9},
+	"Malawi":                   {-13.3, 34.3},
+	"Malaysia":                 {4.2, 102.0},
+	"Mali":                     {17.6, -4.0},
+	"Malta":                    {35.9, 14.4},
+	"Mauritania":               {21.0, -10.9},
+	"Mexico":                   {23.6, -102.6},
+	"Moldova":                  {47.4, 28.4},
+	"Monaco":                   {43.7, 7.4},
+	"Mongolia":                 {46.9, 103.8},
+	"Montenegro":               {42.7, 19.4},
+	"Morocco":                  {31.8, -7.1},
+	"Mozambique":               {-18.7, 35.5},
+	"Namibia":                  {-22.9, 18.5},
+	"Netherlands":              {52.1, 5.3},
+	"New Zealand":              {-40.9, 174.9},
+	"Nicaragua":                {12.9, -85.2},
+	"Niger":                    {17.6, 8.1},
+	"Nigeria":                  {9.1, 8.7},
+	"Norway":                   {60.5, 8.5},
+	"Oman":                     {21.5, 55.9},
+	"Pakistan":                 {30.4, 69.3},
+	"Palau":                    {7.5, 134.6},
+	"Panama":                   {8.5, -80.8},
+	"Papua New Guinea":         {-6.3, 143.9},
+	"Paraguay":                 {-23.4, -58.4},
+	"Peru":                     {-9.2, -75.0},
+	"Philippines":              {12.9, 121.8},
+	"Poland":                   {51.9, 19.1},
+	"Portugal":                 {39.4, -8.2},
+	"Qatar":                    {25.4, 51.2},
+	"Romania":                  {45.9, 25.0},
+	"Rwanda":                   {-1.9, 29.9},
+	"San Marino":               {43.9, 12.5},
+	"Saudi Arabia":             {23.9, 45.1},
+	"Scotland":                 {56.5, -4.2},
+	"Russia":                   {61.5, 105.3},
+	"Senegal":                  {14.5, -14.5},
+	"Serbia":                   {44.0, 21.0},
+	"Sierra Leone":             {8.5, -11.8},
+	"Slovakia":                 {48.7, 19.7},
+	"Slovenia":                 {46.2, 15.0},
+	"Somalia":                  {5.2, 46.2},
+	"South Africa":             {-30.6, 22.9},
+	"Spain":                    {40.5, -3.7},
+	"Sudan":                    {12.9, 30.2},
+	"Suriname":                 {3.9, -56.0},
+	"Swaziland":                {-26.5, 31.5},
+	"Sweden":                   {60.1, 18.6},
+	"Switzerland":              {46.8, 8.2},
+	"Syria":                    {34.8, 39.0},
+	"Taiwan":                   {23.7, 121.0},
+	"Tajikstan":                {38.9, 71.3},
+	"Tanzania":                 {-6.4, 34.9},
+	"Tbilisi":                  {41.7, 44.8},
+	"Thailand":                 {15.9, 101.0},
+	"Togo":                     {8.6, 0.8},
+	"Tunisia":                  {33.9, 9.5},
+	"Turkey":                   {39.0, 35.2},
+	"Turkmenistan":             {39.0, 59.6},
+	"Uganda":                   {1.4, 32.3},
+	"United Arab Emirates":     {23.4, 53.8},
+	"Ukraine":                  {48.4, 31.2},
+	"Uruguay":                  {-32.5, -55.8},
+	"USA":                      {39.8, -98.6},
+	"US":                       {39.8, -98.6},
+	"Uzbekistan":               {41.4, 64.6},
+	"Venezuela":                {6.4, -66.6},
+	"Vietnam":                  {14.1, 108.3},
+	"Wales":                    {52.1, -3.8},
+	"Yemen":                    {15.6, 48.5},
+	"Zambia":                   {-13.1, 27.8},
+	"Zimbabwe":                 {-19.0, 29.2},
+	"alabama":                  {32.8, -86.8},
+	"alaska":                   {64.2, -149.5},
+	"arkansas":                 {34.9, -92.4},
+	"arizona":                  {34.2, -111.7},
+	"california":               {37.2, -119.4},
+	"colorado":                 {39.0, -105.5},
+	"connecticut":              {41.6, -72.7},
+	"delaware":                 {39.0, -75.5},
+	"district of columbia":     {38.9, -77.0},
+	"florida":                  {28.6, -82.4},
+	"georgia usa":              {32.7, -83.4},
+	"hawaii":                   {19.9, -155.6},
+	"iowa":                     {42.1, -93.5},
+	"idaho":                    {44.4, -114.6},
+	"illinois":                 {40.0, -89.2},
+	"indiana":                  {39.9, -86.3},
+	"kentucky":                 {37.5, -85.3},
+	"kansas":                   {38.5, -98.4},
+	"louisiana":                {31.1, -92.0},
+	"massachusetts":            {42.3, -71.8},
+	"maryland":                 {39.0, -76.8},
+	"maine":                    {45.4, -69.2},
+	"michigan":                 {44.3, -85.4},
+	"missouri":                 {38.4, -92.5},
+	"minnesota":                {46.3, -94.3},
+	"mississippi":              {32.7, -89.7},
+	"montana":                  {47.0, -109.6},
+	"north carolina":           {35.6, -79.4},
+	"north dakota":             {47.5, -100.5},
+	"nebraska":                 {41.5, -99.8},
+	"new hampshire":            {43.7, -71.6},
+	"new jersey":               {40.2, -74.7},
+	"new mexico":               {34.4, -106.1},
+	"nevada":                   {39.3, -116.6},
+	"new york":                 {42.9, -75.5},
+	"ohio":                     {40.3, -82.8},
+	"oklahoma":                 {35.6, -97.5},
+	"oregon":                   {43.9, -120.6},
+	"pennsylvania":             {40.9, -77.8},
+	"rhode island":             {41.7, -71.5},
+	"south carolina":           {33.9, -80.9},
+	"south dakota":             {44.4, -100.2},
+	"tennessee":                {35.9, -86.4},
+	"texas":                    {31.5, -99.3},
+	"utah":                     {39.3, -111.7},
+	"virginia":                 {37.5, -78.9},
+	"vermont":                  {44.1, -72.7},
+	"washington":               {47.4, -120.5},
+	"wisconsin":                {44.6, -89.9},
+	"west virginia":            {38.6, -80.6},
+	"wyoming":                  {43.0, -107.6},
+}
@@ -0,0 +1,121 @@
+package cousins
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// stableLevel is the share of resamples in which an entry must
+// keep its order to its neighbours to be considered stable.
+const stableLevel = 0.95
+
+// BootstrapFrequency is a Frequency together with the results
+// of resampling the cousins.
+type BootstrapFrequency struct {
+	Frequency
+	// Low and High are the bounds of the 95% confidence interval of NCousins.
+	Low, High int
+	// Stability is the share of resamples in which the entry keeps
+	// its order to the entries above and below it. Tied entries keep
+	// their order if they stay tied.
+	Stability float64
+}
+
+// Stable reports whether the rank of the entry holds in
+// at least 95% of all resamples.
+func (b *BootstrapFrequency) Stable() bool {
+	return b.Stability >= stableLevel
+}
+
+// BootstrapLocations resamples the Ancestries n times and returns confidence
+// intervals and rank stability for the locations of freqs, which must be
+// ordered by rank. r is the source of random numbers.
+func (a *Ancestries) BootstrapLocations(freqs Frequencies, n int, r *rand.Rand) []BootstrapFrequency {
+	return a.bootstrap(freqs, n, r, func(anc Ancestry) map[string]bool { return anc.Locations })
+}
+
+// BootstrapNames resamples the Ancestries n times and returns confidence
+// intervals and rank stability for the surnames of freqs, which must be
+// ordered by rank. r is the source of random numbers.
+func (a *Ancestries) BootstrapNames(freqs Frequencies, n int, r *rand.Rand) []BootstrapFrequency {
+	return a.bootstrap(freqs, n, r, func(anc Ancestry) map[string]bool { return anc.Names })
+}
+
+// bootstrap draws n samples with replacement from the Ancestries, each
+// as large as the Ancestries, and counts the entries of freqs in every
+// sample. The access function accFunc determines which field of the
+// Ancestries is counted.
+func (a *Ancestries) bootstrap(freqs Frequencies, n int, r *rand.Rand, accFunc func(Ancestry) map[string]bool) []BootstrapFrequency {
+	result := make([]BootstrapFrequency, len(freqs))
+	for i, freq := range freqs {
+		result[i] = BootstrapFrequency{Frequency: freq, Low: freq.NCousins, High: freq.NCousins, Stability: 1}
+	}
+	if n <= 0 || len(*a) == 0 || len(freqs) == 0 {
+		return result
+	}
+
+	// Find the cousins that contribute to each entry.
+	positions := make(map[string][]int, len(freqs))
+	for i, freq := range freqs {
+		lower := strings.ToLower(freq.Name)
+		positions[lower] = append(positions[lower], i)
+	}
+	members := make([][]int, len(freqs))
+	for c, ancestry := range *a {
+		for name, _ := range accFunc(ancestry) {
+			for _, i := range positions[name] {
+				members[i] = append(members[i], c)
+			}
+		}
+	}
+
+	// counts[i][s] is the number of cousins of entry i in sample s.
+	counts := make([][]int, len(freqs))
+	for i := range counts {
+		counts[i] = make([]int, n)
+	}
+	weights := make([]int, len(*a))
+	for s := 0; s < n; s++ {
+		for c := range weights {
+			weights[c] = 0
+		}
+		for k := 0; k < len(weights); k++ {
+			weights[r.Intn(len(weights))]++
+		}
+		for i, cousins := range members {
+			sum := 0
+			for _, c := range cousins {
+				sum += weights[c]
+			}
+			counts[i][s] = sum
+		}
+	}
+
+	// Share of samples in which entry i keeps its order to entry i+1,
+	// that is it stays ahead or, if both are tied, stays tied.
+	above := make([]float64, len(freqs))
+	for i := 0; i+1 < len(freqs); i++ {
+		tied := freqs[i].NCousins == freqs[i+1].NCousins
+		held := 0
+		for s := 0; s < n; s++ {
+			if (counts[i][s] == counts[i+1][s]) == tied && counts[i][s] >= counts[i+1][s] {
+				held++
+			}
+		}
+		above[i] = float64(held) / float64(n)
+	}
+	for i := range result {
+		if i+1 < len(freqs) {
+			result[i].Stability = above[i]
+		}
+		if i > 0 && above[i-1] < result[i].Stability {
+			result[i].Stability = above[i-1]
+		}
+		sorted := append([]int{}, counts[i]...)
+		sort.Ints(sorted)
+		result[i].Low = sorted[int(0.025*float64(n-1)+0.5)]
+		result[i].High = sorted[int(0.975*float64(n-1)+0.5)]
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package cousins
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// bootstrapAncestries returns 30 cousins with the surname Smith,
+// 5 with Jones and 5 with Meier.
+func bootstrapAncestries() Ancestries {
+	var result Ancestries
+	for i := 0; i < 40; i++ {
+		switch {
+		case i < 30:
+			result = append(result, NewAncestry("Smith"))
+		case i < 35:
+			result = append(result, NewAncestry("Jones"))
+		default:
+			result = append(result, NewAncestry("Meier"))
+		}
+	}
+	return result
+}
+
+func TestBootstrapNames(t *testing.T) {
+	ancestries := bootstrapAncestries()
+	freqs := Frequencies{{NCousins: 30, Name: "Smith"}, {NCousins: 5, Name: "Jones"}, {NCousins: 5, Name: "Meier"}}
+	result := ancestries.BootstrapNames(freqs, 1000, rand.New(rand.NewSource(1)))
+	if len(result) != 3 {
+		t.Fatalf("got %d results, want 3", len(result))
+	}
+	for _, b := range result {
+		if b.Low > b.NCousins || b.High < b.NCousins || b.Low < 0 || b.High > len(ancestries) {
+			t.Errorf("%s: interval %d-%d does not contain %d", b.Name, b.Low, b.High, b.NCousins)
+		}
+	}
+	// The binomial standard deviation of Smith is about 2.7 cousins.
+	smith := result[0]
+	if smith.Low < 22 || smith.Low > 28 || smith.High < 32 || smith.High > 38 {
+		t.Errorf("interval of Smith = %d-%d, want about 25-35", smith.Low, smith.High)
+	}
+	// The clear leader keeps its rank, the tied entries do not stay tied.
+	if !smith.Stable() {
+		t.Errorf("Smith has stability %v, want stable", smith.Stability)
+	}
+	if result[1].Stable() || result[2].Stable() {
+		t.Errorf("tied entries are stable: %v, %v", result[1].Stability, result[2].Stability)
+	}
+	if result[1].Stability > smith.Stability {
+		t.Errorf("Jones is more stable than Smith: %v > %v", result[1].Stability, smith.Stability)
+	}
+
+	// The same seed gives the same results.
+	again := ancestries.BootstrapNames(freqs, 1000, rand.New(rand.NewSource(1)))
+	if !reflect.DeepEqual(result, again) {
+		t.Errorf("results differ for the same seed: %v and %v", result, again)
+	}
+}
+
+func TestBootstrapWithoutResamples(t *testing.T) {
+	ancestries := bootstrapAncestries()
+	freqs := Frequencies{{NCousins: 30, Name: "Smith"}}
+	result := ancestries.BootstrapNames(freqs, 0, rand.New(rand.NewSource(1)))
+	if b := result[0]; b.Low != 30 || b.High != 30 || b.Stability != 1 {
+		t.Errorf("result = %+v, want interval 30-30 and stability 1", b)
+	}
+}
@@ -0,0 +1,124 @@
+package cousins
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// normalizationVersion identifies the rules for parsing and normalizing
+// ancestral information. It must be incremented whenever the parser
+// or the normalization tables change, so that outdated cache entries
+// are not used any more.
+const normalizationVersion = 14
+
+// Cache stores parsed matches files on disk. Entries are identified
+// by the content of the file, the parsing options and the
+// normalization version, so that changed files are parsed again.
+type Cache struct {
+	dir string
+}
+
+// cacheEntry is the content of a cache file.
+type cacheEntry struct {
+	// Lines are the original lines of the Ancestries,
+	// which are not exported and must be stored separately.
+	Lines      []string
+	Ancestries Ancestries
+}
+
+// NewCache creates a Cache in the directory dir.
+// The directory is created if it does not exist.
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// DefaultCacheDir returns the directory for cache files
+// within the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "familyties"), nil
+}
+
+// NewAncestries works like the function NewAncestries, but returns
+// the Ancestries from the cache if the file has been parsed before.
+// If c is nil, the file is always parsed.
+func (c *Cache) NewAncestries(filename string, namesCol int, encoding string) (Ancestries, error) {
+	if c == nil {
+		return NewAncestries(filename, namesCol, encoding)
+	}
+	inbytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(inbytes)
+	key := fmt.Sprintf("%s-%d-%s-v%d", hex.EncodeToString(hash[:]), namesCol, encoding, normalizationVersion)
+	cachefile := filepath.Join(c.dir, key+".gob")
+
+	// Use cached Ancestries if possible. Damaged cache files are ignored.
+	if ancestries, err := readCacheFile(cachefile); err == nil {
+		return ancestries, nil
+	}
+
+	ancestries, err := ParseAncestries(inbytes, namesCol, encoding)
+	if err != nil {
+		return nil, err
+	}
+	// A cache that can not be written is not an error,
+	// it just does not speed things up.
+	writeCacheFile(cachefile, ancestries)
+	return ancestries, nil
+}
+
+// readCacheFile reads Ancestries from a cache file.
+func readCacheFile(filename string) (Ancestries, error) {
+	infile, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer infile.Close()
+	var entry cacheEntry
+	if err := gob.NewDecoder(infile).Decode(&entry); err != nil {
+		return nil, err
+	}
+	if len(entry.Lines) != len(entry.Ancestries) {
+		return nil, fmt.Errorf("damaged cache file %s", filename)
+	}
+	for i := range entry.Ancestries {
+		entry.Ancestries[i].line = entry.Lines[i]
+	}
+	return entry.Ancestries, nil
+}
+
+// writeCacheFile writes Ancestries to a cache file. The file is
+// written to a temporary file first, so that concurrent readers
+// never see incomplete files.
+func writeCacheFile(filename string, ancestries Ancestries) error {
+	entry := cacheEntry{Lines: make([]string, len(ancestries)), Ancestries: ancestries}
+	for i, ancestry := range ancestries {
+		entry.Lines[i] = ancestry.line
+	}
+	outfile, err := ioutil.TempFile(filepath.Dir(filename), "tmp-")
+	if err != nil {
+		return err
+	}
+	err = gob.NewEncoder(outfile).Encode(entry)
+	if cerr := outfile.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(outfile.Name())
+		return err
+	}
+	return os.Rename(outfile.Name(), filename)
+}
@@ -0,0 +1,91 @@
+package cousins
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Overlap shows the ancestral information a cousin shares
+// with a family tree.
+type Overlap struct {
+	// Match is the name of the cousin.
+	Match string
+	// Names are the shared ancestral surnames.
+	Names []string
+	// Locations are the shared ancestral locations.
+	Locations []string
+	// Pairs are the shared combinations of surname and location,
+	// written as "surname@location".
+	Pairs []string
+	// Score estimates the likelihood of a traceable common ancestor.
+	// Higher is better.
+	Score float64
+}
+
+// Overlaps is a list of Overlap that satisfies the sort.Interface.
+type Overlaps []Overlap
+
+func (o *Overlaps) Len() int {
+	return len(*o)
+}
+
+func (o *Overlaps) Less(i, j int) bool {
+	return (*o)[i].Score < (*o)[j].Score
+}
+
+func (o *Overlaps) Swap(i, j int) {
+	(*o)[i], (*o)[j] = (*o)[j], (*o)[i]
+}
+
+// CompareWith scores each Ancestry by its overlap with a family tree.
+// Rare surnames and locations are more meaningful than common ones,
+// so each shared surname and location is weighted by how seldom it
+// occurs among all Ancestries. Locations count half, because places
+// are shared by many unrelated families. A surname that comes from
+// the same location in both the tree and the cousin's entries counts
+// as a pair and adds the weights of both once more.
+// Only cousins with at least one shared surname or location are
+// returned. The result is not sorted.
+func (a *Ancestries) CompareWith(tree Ancestry) Overlaps {
+	nameFreqs := make(map[string]int)
+	locFreqs := make(map[string]int)
+	for _, ancestry := range *a {
+		for name, _ := range ancestry.Names {
+			nameFreqs[name]++
+		}
+		for loc, _ := range ancestry.Locations {
+			locFreqs[loc]++
+		}
+	}
+	n := float64(len(*a))
+	weight := func(freq int) float64 {
+		return math.Log((1+n)/(1+float64(freq))) + 1
+	}
+
+	treePairs := tree.pairs()
+	var result Overlaps
+	for _, ancestry := range *a {
+		overlap := Overlap{Match: ancestry.Match}
+		for name, _ := range commons(ancestry.Names, tree.Names) {
+			overlap.Names = append(overlap.Names, name)
+			overlap.Score += weight(nameFreqs[name])
+		}
+		for loc, _ := range commons(ancestry.Locations, tree.Locations) {
+			overlap.Locations = append(overlap.Locations, loc)
+			overlap.Score += weight(locFreqs[loc]) / 2
+		}
+		for pair, _ := range commons(ancestry.pairs(), treePairs) {
+			overlap.Pairs = append(overlap.Pairs, pair)
+			parts := strings.SplitN(pair, "@", 2)
+			overlap.Score += weight(nameFreqs[parts[0]]) + weight(locFreqs[parts[1]])
+		}
+		if overlap.Score > 0 {
+			sort.Strings(overlap.Names)
+			sort.Strings(overlap.Locations)
+			sort.Strings(overlap.Pairs)
+			result = append(result, overlap)
+		}
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package cousins
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareWith(t *testing.T) {
+	tree := NewAncestry("Smith (Virginia) / Fischer (Cork)")
+	a := NewAncestry("Smith (Virginia) / Jones (Ohio)")
+	a.Match = "Anna"
+	b := NewAncestry("Weber (Bavaria)")
+	b.Match = "Bert"
+	c := NewAncestry("Fischer (Bavaria)")
+	c.Match = "Carl"
+	ancestries := Ancestries{a, b, c}
+
+	overlaps := make(map[string]Overlap)
+	for _, overlap := range ancestries.CompareWith(tree) {
+		overlaps[overlap.Match] = overlap
+	}
+	if _, ok := overlaps["Bert"]; ok || len(overlaps) != 2 {
+		t.Fatalf("overlaps = %v, want only Anna and Carl", overlaps)
+	}
+	anna := overlaps["Anna"]
+	if !reflect.DeepEqual(anna.Names, []string{"smith"}) {
+		t.Errorf("shared names of Anna = %v, want [smith]", anna.Names)
+	}
+	if !reflect.DeepEqual(anna.Locations, []string{"usa", "virginia"}) {
+		t.Errorf("shared locations of Anna = %v, want [usa virginia]", anna.Locations)
+	}
+	if len(anna.Pairs) == 0 || anna.Pairs[0] != "smith@usa" && anna.Pairs[0] != "smith@virginia" {
+		t.Errorf("shared pairs of Anna = %v, want smith with virginia", anna.Pairs)
+	}
+	carl := overlaps["Carl"]
+	if !reflect.DeepEqual(carl.Names, []string{"fischer"}) || len(carl.Locations) != 0 || len(carl.Pairs) != 0 {
+		t.Errorf("overlap of Carl = %+v, want only the surname fischer", carl)
+	}
+	// A shared pair of surname and location is better than a surname alone.
+	if anna.Score <= carl.Score {
+		t.Errorf("score of Anna %v must be higher than score of Carl %v", anna.Score, carl.Score)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -33,202 +34,290 @@ type Ancestry struct {
 	// Names are the different ancestral surnames.
 	Names     map[string]bool
 	Locations map[string]bool
+	// Entries are the entries of the line in their original order.
+	// Each entry links a surname to its own places and years.
+	// Years are not contained in any other field.
+	Entries []Entry
+	// Match is the name of the cousin as given in the matches file.
+	Match string
+	// MatchDate is the date when the match was found.
+	// It is the zero time if the date is unknown.
+	MatchDate time.Time
+	// YHaplogroup and MtHaplogroup are the Y-DNA and mtDNA
+	// haplogroups of the cousin, if known.
+	YHaplogroup  string
+	MtHaplogroup string
+	// XMatch is true if the cousin also shares DNA
+	// on the X chromosome.
+	XMatch bool
+	// Notes are the user's research notes about the match.
+	Notes string
+	// Tags are the tags like "#paternal" found in the Notes,
+	// in small caps and without "#".
+	Tags map[string]bool
+	// SharedCM is the total length of the shared DNA segments
+	// in centimorgans. It is 0 if unknown.
+	SharedCM float64
+	// Relationship is the relationship predicted by the testing
+	// company, for example "3rd Cousin" or "2nd Cousin - 4th Cousin".
+	Relationship string
 }
 
 // NewAncestry creates an Ancstry from a single line of the
 // FamilyFinder matches file. Double entries are eliminated.
 // All names and locations are returned in small caps.
 func NewAncestry(line string) Ancestry {
+	return newAncestry(line, parseEntry)
+}
+
+// newAncestry creates an Ancestry from a line whose entries
+// are parsed by parse.
+func newAncestry(line string, parse func(entry string) Entry) Ancestry {
 	line = strings.ToLower(line)
 	names := make(map[string]bool)
 	locations := make(map[string]bool)
-	tokens := extractTokens(line)
+	var entries []Entry
+	// Years are not useful as words or tokens.
+	text, _ := extractYears(line)
+	text = expandCounties(text)
+	tokens := extractTokens(text)
 	tokens = normalizeTokens(tokens)
-	words := extractWords(line)
+	words := extractWords(text)
 	words = normalizeTokens(words)
+	// Regions like "nova scotia" are expanded into tokens like
+	// "canada" that must also be found as words.
+	for token, _ := range tokens {
+		if strings.IndexFunc(token, isWordDelimiter) < 0 {
+			words[token] = true
+		}
+	}
 
-	// Entries are separated by "/". Each entry contains
-	// a name and a sometimes also a location. A location
-	// may consist of several parts, for example a town and
-	// a country.
-	entries := strings.Split(line, "/")
-	for _, entry := range entries {
-		entry = strings.TrimSpace(entry)
-
-		// Extract name.
-		name := ""
-		pos := strings.IndexRune(entry, '(')
-		if pos > 0 {
-			name = entry[0:pos]
-		} else {
-			name = entry
+	// Entries are separated by "/".
+	for _, part := range strings.Split(line, "/") {
+		entry := parse(part)
+		if entry.Surname == "" && len(entry.Places) == 0 {
+			continue
+		}
+		entries = append(entries, entry)
+		if entry.Surname != "" {
+			names[entry.Surname] = true
 		}
-		name = strings.TrimFunc(name, isWordDelimiter)
-		if len(name) > 1 {
-			names[name] = true
+		for _, place := range entry.Places {
+			locations[place] = true
 		}
+	}
+	return Ancestry{line: line, Words: words, Tokens: tokens, Names: names, Locations: locations, Entries: entries}
+}
 
-		// Extract locations.
-		locationString := ""
-		if pos > 0 && pos < len(entry)-1 {
-			locationString = entry[pos+1:]
-			locationString = strings.TrimFunc(locationString, isWordDelimiter)
-			if len(locationString) > 1 {
-				locs := extractTokens(locationString)
-				locs = normalizeTokens(locs)
-				for loc, _ := range locs {
-					locations[loc] = true
-				}
-			}
+// parseEntry extracts the name, the normalized locations and the
+// years from a single entry of a line. Each entry contains a name
+// and a sometimes also a location. A location may consist of several
+// parts, for example a town and a country. The location is usually
+// given in braces, but in entries like "O'Brien b. 1820 Cork"
+// it follows the years.
+func parseEntry(entry string) Entry {
+	entry = strings.TrimSpace(entry)
+
+	// Split entry into name and location.
+	name, locationString := entry, ""
+	if pos := strings.IndexRune(entry, '('); pos > 0 {
+		name, locationString = entry[0:pos], entry[pos+1:]
+	} else if pos := yearPattern.FindStringIndex(entry); pos != nil {
+		name, locationString = entry[0:pos[0]], entry[pos[0]:]
+	}
+	name, nameYears := extractYears(name)
+	places, locationYears := parsePlaces(locationString)
+
+	// Extract name.
+	name = strings.TrimFunc(name, isWordDelimiter)
+	if len(name) <= 1 {
+		name = ""
+	}
+	return Entry{Surname: name, Places: places, Years: nameYears.Union(locationYears)}
+}
+
+// parsePlaces extracts the normalized locations and the years
+// from the location part of an entry.
+func parsePlaces(locationString string) ([]string, Years) {
+	locationString, years := extractYears(locationString)
+	locationString = expandCounties(locationString)
+	var places []string
+	locationString = strings.TrimFunc(locationString, isWordDelimiter)
+	if len(locationString) > 1 {
+		places = sortedKeys(normalizeTokens(extractTokens(locationString)))
+	}
+	return places, years
+}
+
+// pairs returns the combinations of surnames and locations that
+// occur together in the same entry. The pairs are written as
+// "surname@location".
+func (a *Ancestry) pairs() map[string]bool {
+	result := make(map[string]bool)
+	for _, entry := range a.Entries {
+		if entry.Surname == "" {
+			continue
+		}
+		for _, place := range entry.Places {
+			result[entry.Surname+"@"+place] = true
 		}
 	}
-	return Ancestry{line: line, Words: words, Tokens: tokens, Names: names, Locations: locations}
+	return result
+}
+
+// Line returns the original ancestral information in small caps.
+func (a *Ancestry) Line() string {
+	return a.line
 }
 
 // Contains checks if the Ancestry contains name.
-// The method checks words and tokens.
+// The method checks words and tokens. Names like "surname:schmidt",
+// "place:hessen" or "surname:schmidt@hessen" are checked against
+// the Entries, names like "tag:paternal" against the Tags.
 func (a *Ancestry) Contains(name string) bool {
-	name = strings.ToLower(name)
+	name = normalizeTerm(name)
+	if strings.HasPrefix(name, surnamePrefix) || strings.HasPrefix(name, placePrefix) {
+		return a.entryKeys()[name]
+	}
+	if strings.HasPrefix(name, tagPrefix) {
+		return a.Tags[strings.TrimPrefix(name, tagPrefix)]
+	}
 	return a.Words[name] || a.Tokens[name]
 }
 
+// dirtyTags is a map of tokens that are transformed
+// into the normalized form. Regions outside the USA
+// are contained in regionTags.
+var dirtyTags = map[string][]string{
+	"gt":                       {}, // part of &gt;
+	"amp":                      {}, // part of &amp;
+	"ii":                       {},
+	"???":                      {},
+	"now":                      {},
+	"also":                     {},
+	"unknown":                  {},
+	"al":                       {"alabama", "usa"},
+	"ak":                       {"alaska", "usa"},
+	"ar":                       {"arkansas", "usa"},
+	"az":                       {"arizona", "usa"},
+	"ca":                       {"california", "usa"},
+	"co":                       {"colorado", "usa"},
+	"ct":                       {"connecticut", "usa"},
+	"de":                       {"delaware", "usa"},
+	"dc":                       {"district of columbia", "usa"},
+	"fl":                       {"florida", "usa"},
+	"ga":                       {"georgia usa", "usa"},
+	"hi":                       {"hawaii", "usa"},
+	"ia":                       {"iowa", "usa"},
+	"id":                       {"idaho", "usa"},
+	"il":                       {"illinois", "usa"},
+	"in":                       {"indiana", "usa"},
+	"ky":                       {"kentucky", "usa"},
+	"ks":                       {"kansas", "usa"},
+	"la":                       {"louisiana", "usa"},
+	"ma":                       {"massachusetts", "usa"},
+	"md":                       {"maryland", "usa"},
+	"me":                       {"maine", "usa"},
+	"mi":                       {"michigan", "usa"},
+	"mo":                       {"missouri", "usa"},
+	"mn":                       {"minnesota", "usa"},
+	"ms":                       {"mississippi", "usa"},
+	"mt":                       {"montana", "usa"},
+	"nc":                       {"north carolina", "usa"},
+	"nd":                       {"north dakota", "usa"},
+	"ne":                       {"nebraska", "usa"},
+	"nh":                       {"new hampshire", "usa"},
+	"nj":                       {"new jersey", "usa"},
+	"nm":                       {"new mexico", "usa"},
+	"nv":                       {"nevada", "usa"},
+	"ny":                       {"new york", "usa"},
+	"nyc":                      {"new york", "usa"},
+	"oh":                       {"ohio", "usa"},
+	"ok":                       {"oklahoma", "usa"},
+	"or":                       {"oregon", "usa"},
+	"pa":                       {"pennsylvania", "usa"},
+	"ri":                       {"rhode island", "usa"},
+	"sc":                       {"south carolina", "usa"},
+	"sd":                       {"south dakota", "usa"},
+	"tn":                       {"tennessee", "usa"},
+	"tx":                       {"texas", "usa"},
+	"uk":                       {"united kingdom"},
+	"us":                       {"usa"},
+	"ut":                       {"utah", "usa"},
+	"va":                       {"virginia", "usa"},
+	"vt":                       {"vermont", "usa"},
+	"wa":                       {"washington", "usa"},
+	"wi":                       {"wisconsin", "usa"},
+	"wv":                       {"west virginia", "usa"},
+	"wy":                       {"wyoming", "usa"},
+	"alabama":                  {"alabama", "usa"},
+	"alaska":                   {"alaska", "usa"},
+	"arkansas":                 {"arkansas", "usa"},
+	"arizona":                  {"arizona", "usa"},
+	"california":               {"california", "usa"},
+	"colorado":                 {"colorado", "usa"},
+	"connecticut":              {"connecticut", "usa"},
+	"delaware":                 {"delaware", "usa"},
+	"district of columbia":     {"district of columbia", "usa"},
+	"florida":                  {"florida", "usa"},
+	"georgia usa":              {"georgia usa", "usa"},
+	"hawaii":                   {"hawaii", "usa"},
+	"iowa":                     {"iowa", "usa"},
+	"idaho":                    {"idaho", "usa"},
+	"illinois":                 {"illinois", "usa"},
+	"indiana":                  {"indiana", "usa"},
+	"kentucky":                 {"kentucky", "usa"},
+	"kansas":                   {"kansas", "usa"},
+	"louisiana":                {"louisiana", "usa"},
+	"massachusetts":            {"massachusetts", "usa"},
+	"maryland":                 {"maryland", "usa"},
+	"maine":                    {"maine", "usa"},
+	"michigan":                 {"michigan", "usa"},
+	"missouri":                 {"missouri", "usa"},
+	"minnesota":                {"minnesota", "usa"},
+	"mississippi":              {"mississippi", "usa"},
+	"montana":                  {"montana", "usa"},
+	"north carolina":           {"north carolina", "usa"},
+	"north dakota":             {"north dakota", "usa"},
+	"nebraska":                 {"nebraska", "usa"},
+	"new hampshire":            {"new hampshire", "usa"},
+	"new jersey":               {"new jersey", "usa"},
+	"new mexico":               {"new mexico", "usa"},
+	"nevada":                   {"nevada", "usa"},
+	"new york":                 {"new york", "usa"},
+	"ohio":                     {"ohio", "usa"},
+	"oklahoma":                 {"oklahoma", "usa"},
+	"oregon":                   {"oregon", "usa"},
+	"pennsylvania":             {"pennsylvania", "usa"},
+	"rhode island":             {"rhode island", "usa"},
+	"south carolina":           {"south carolina", "usa"},
+	"south dakota":             {"south dakota", "usa"},
+	"tennessee":                {"tennessee", "usa"},
+	"texas":                    {"texas", "usa"},
+	"utah":                     {"utah", "usa"},
+	"virginia":                 {"virginia", "usa"},
+	"vermont":                  {"vermont", "usa"},
+	"washington":               {"washington", "usa"},
+	"wisconsin":                {"wisconsin", "usa"},
+	"west virginia":            {"west virginia", "usa"},
+	"wyoming":                  {"wyoming", "usa"},
+	"danmark":                  {"denmark"},
+	"deutschland":              {"germany"},
+	"russian federation":       {"russia"},
+	"united states of america": {"usa"},
+	"united states":            {"usa"},
+	"w virginia":               {"west virginia", "usa"},
+}
+
 // normalizeTokens transforms the given tokens into a normalized form.
 // Abbreviations are expanded, some words are translated into English,
 // and junk is thrown away. The tokens should be converted to lower case
 // before calling this function.
 func normalizeTokens(tokens map[string]bool) map[string]bool {
 	result := make(map[string]bool)
-
-	// dirtyTags is a map of tokens that are transformed
-	// into the normalized form.
-	dirtyTags := map[string][]string{
-		"gt":                       {}, // part of &gt;
-		"amp":                      {}, // part of &amp;
-		"ii":                       {},
-		"???":                      {},
-		"now":                      {},
-		"also":                     {},
-		"unknown":                  {},
-		"al":                       {"alabama", "usa"},
-		"ak":                       {"alaska", "usa"},
-		"ar":                       {"arkansas", "usa"},
-		"az":                       {"arizona", "usa"},
-		"ca":                       {"california", "usa"},
-		"co":                       {"colorado", "usa"},
-		"ct":                       {"connecticut", "usa"},
-		"de":                       {"delaware", "usa"},
-		"dc":                       {"district of columbia", "usa"},
-		"fl":                       {"florida", "usa"},
-		"ga":                       {"georgia usa", "usa"},
-		"hi":                       {"hawaii", "usa"},
-		"ia":                       {"iowa", "usa"},
-		"id":                       {"idaho", "usa"},
-		"il":                       {"illinois", "usa"},
-		"in":                       {"indiana", "usa"},
-		"ky":                       {"kentucky", "usa"},
-		"ks":                       {"kansas", "usa"},
-		"la":                       {"louisiana", "usa"},
-		"ma":                       {"massachusetts", "usa"},
-		"md":                       {"maryland", "usa"},
-		"me":                       {"maine", "usa"},
-		"mi":                       {"michigan", "usa"},
-		"mo":                       {"missouri", "usa"},
-		"mn":                       {"minnesota", "usa"},
-		"ms":                       {"mississippi", "usa"},
-		"mt":                       {"montana", "usa"},
-		"nc":                       {"north carolina", "usa"},
-		"nd":                       {"north dakota", "usa"},
-		"ne":                       {"nebraska", "usa"},
-		"nh":                       {"new hampshire", "usa"},
-		"nj":                       {"new jersey", "usa"},
-		"nm":                       {"new mexico", "usa"},
-		"nv":                       {"nevada", "usa"},
-		"ny":                       {"new york", "usa"},
-		"nyc":                      {"new york", "usa"},
-		"oh":                       {"ohio", "usa"},
-		"ok":                       {"oklahoma", "usa"},
-		"or":                       {"oregon", "usa"},
-		"pa":                       {"pennsylvania", "usa"},
-		"ri":                       {"rhode island", "usa"},
-		"sc":                       {"south carolina", "usa"},
-		"sd":                       {"south dakota", "usa"},
-		"tn":                       {"tennessee", "usa"},
-		"tx":                       {"texas", "usa"},
-		"uk":                       {"united kingdom"},
-		"us":                       {"usa"},
-		"ut":                       {"utah", "usa"},
-		"va":                       {"virginia", "usa"},
-		"vt":                       {"vermont", "usa"},
-		"wa":                       {"washington", "usa"},
-		"wi":                       {"wisconsin", "usa"},
-		"wv":                       {"west virginia", "usa"},
-		"wy":                       {"wyoming", "usa"},
-		"alabama":                  {"alabama", "usa"},
-		"alaska":                   {"alaska", "usa"},
-		"arkansas":                 {"arkansas", "usa"},
-		"arizona":                  {"arizona", "usa"},
-		"california":               {"california", "usa"},
-		"colorado":                 {"colorado", "usa"},
-		"connecticut":              {"connecticut", "usa"},
-		"delaware":                 {"delaware", "usa"},
-		"district of columbia":     {"district of columbia", "usa"},
-		"florida":                  {"florida", "usa"},
-		"georgia usa":              {"georgia usa", "usa"},
-		"hawaii":                   {"hawaii", "usa"},
-		"iowa":                     {"iowa", "usa"},
-		"idaho":                    {"idaho", "usa"},
-		"illinois":                 {"illinois", "usa"},
-		"indiana":                  {"indiana", "usa"},
-		"kentucky":                 {"kentucky", "usa"},
-		"kansas":                   {"kansas", "usa"},
-		"louisiana":                {"louisiana", "usa"},
-		"massachusetts":            {"massachusetts", "usa"},
-		"maryland":                 {"maryland", "usa"},
-		"maine":                    {"maine", "usa"},
-		"michigan":                 {"michigan", "usa"},
-		"missouri":                 {"missouri", "usa"},
-		"minnesota":                {"minnesota", "usa"},
-		"mississippi":              {"mississippi", "usa"},
-		"montana":                  {"montana", "usa"},
-		"north carolina":           {"north carolina", "usa"},
-		"north dakota":             {"north dakota", "usa"},
-		"nebraska":                 {"nebraska", "usa"},
-		"new hampshire":            {"new hampshire", "usa"},
-		"new jersey":               {"new jersey", "usa"},
-		"new mexico":               {"new mexico", "usa"},
-		"nevada":                   {"nevada", "usa"},
-		"new york":                 {"new york", "usa"},
-		"ohio":                     {"ohio", "usa"},
-		"oklahoma":                 {"oklahoma", "usa"},
-		"oregon":                   {"oregon", "usa"},
-		"pennsylvania":             {"pennsylvania", "usa"},
-		"rhode island":             {"rhode island", "usa"},
-		"south carolina":           {"south carolina", "usa"},
-		"south dakota":             {"south dakota", "usa"},
-		"tennessee":                {"tennessee", "usa"},
-		"texas":                    {"texas", "usa"},
-		"utah":                     {"utah", "usa"},
-		"virginia":                 {"virginia", "usa"},
-		"vermont":                  {"vermont", "usa"},
-		"washington":               {"washington", "usa"},
-		"wisconsin":                {"wisconsin", "usa"},
-		"west virginia":            {"west virginia", "usa"},
-		"wyoming":                  {"wyoming", "usa"},
-		"danmark":                  {"denmark"},
-		"deutschland":              {"germany"},
-		"pommern":                  {"pomerania"},
-		"preußen":                  {"prussia"},
-		"preussen":                 {"prussia"},
-		"westpreussen":             {"west prussia"},
-		"russian federation":       {"russia"},
-		"united states of america": {"usa"},
-		"united states":            {"usa"},
-		"vorpommern":               {"western pomerania"},
-		"w virginia":               {"west virginia", "usa"},
-	}
 	for token, _ := range tokens {
 		// Check if token matches dirty tags.
-		if cleanTokens, ok := dirtyTags[token]; ok {
+		if cleanTokens, ok := cleanTags(token); ok {
 			for _, clean := range cleanTokens {
 				result[clean] = true
 			}
@@ -236,8 +325,15 @@ func normalizeTokens(tokens map[string]bool) map[string]bool {
 			// Check each single word of token for dirty matches.
 			words := strings.FieldsFunc(token, isWordDelimiter)
 			cleanWords := make([]string, 0, len(words))
-			for _, word := range words {
-				if cleanTokens, ok := dirtyTags[word]; ok {
+			for i, word := range words {
+				if word == "county" && i+1 < len(words) {
+					// "county cork" is normalized to "cork", but US
+					// counties like "orange county" keep the word.
+					if _, ok := cleanTags("county " + words[i+1]); ok {
+						continue
+					}
+				}
+				if cleanTokens, ok := cleanTags(word); ok {
 					if len(cleanTokens) == 1 {
 						cleanWords = append(cleanWords, cleanTokens...)
 					} else if len(cleanTokens) > 1 {
@@ -256,6 +352,7 @@ func normalizeTokens(tokens map[string]bool) map[string]bool {
 			}
 		}
 	}
+	addContextTags(tokens, result)
 	return result
 }
 
@@ -310,51 +407,61 @@ func isWordDelimiter(c rune) bool {
 // Ancestries provides a convenient list for the Ancestry type.
 type Ancestries []Ancestry
 
-// NewAncestries creates Ancestries from a Family Finder matches file.
-// The file must be in CSV format. namesCol is the number of the column
-// that contains the ancestral informations.
-func NewAncestries(filename string, namesCol int) (Ancestries, error) {
-	// Read whole file.
+// NewAncestries creates Ancestries from a matches file in CSV format.
+// The format of the file is detected from the column captions.
+// Supported are Family Finder, AncestryDNA, MyHeritage and 23andMe
+// match lists, tree surname exports and GEDmatch one-to-many lists.
+// namesCol is the number of the column that contains the ancestral
+// informations in Family Finder files. encoding is the character
+// encoding of the file, for example "utf-8", "utf-16le" or "windows-1252".
+// If encoding is "auto" or empty, the encoding is detected automatically.
+func NewAncestries(filename string, namesCol int, encoding string) (Ancestries, error) {
 	inbytes, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
+	return ParseAncestries(inbytes, namesCol, encoding)
+}
 
-	// Check if file contains UTF8 byte order mark and drop it if necessary.
-	var dataReader *bytes.Reader
-	BOM := []byte{0xEF, 0xBB, 0xBF}
-	if bytes.Compare(inbytes[:3], BOM) == 0 {
-		dataReader = bytes.NewReader(inbytes[3:])
-	} else {
-		dataReader = bytes.NewReader(inbytes)
-	}
-
-	// Read all CSV records from UTF8 buffer.
-	csvReader := csv.NewReader(dataReader)
-	records, err := csvReader.ReadAll()
+// ParseAncestries creates Ancestries from the content of a matches file.
+// See NewAncestries.
+func ParseAncestries(data []byte, namesCol int, encoding string) (Ancestries, error) {
+	records, err := parseCSV(data, encoding)
 	if err != nil {
 		return nil, err
 	}
-
-	// Extract specified column.
-	column := make([]string, len(records))
-	for i := 0; i < len(records); i++ {
-		column[i] = records[i][namesCol]
+	if len(records) == 0 {
+		return nil, errors.New("empty file")
 	}
+	header, rows := records[0], records[1:]
+	format := detectFormat(header)
+	return format.read(newColumns(header), rows, namesCol)
+}
 
-	// Drop column caption.
-	if len(column) > 0 {
-		column = column[1:]
-	} else {
-		return nil, errors.New("empty file")
+// readCSV reads all records from a file in CSV format.
+// See parseCSV.
+func readCSV(filename string, encoding string) ([][]string, error) {
+	inbytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
 	}
+	return parseCSV(inbytes, encoding)
+}
 
-	// Parse column.
-	result := make([]Ancestry, len(column))
-	for i, line := range column {
-		result[i] = NewAncestry(line)
+// parseCSV reads all records from data in CSV format.
+// data is converted to UTF8 before parsing.
+// Records may have different numbers of fields.
+func parseCSV(data []byte, encoding string) ([][]string, error) {
+	// Convert content to UTF8. Byte order marks are dropped.
+	utf8bytes, err := decodeText(data, encoding)
+	if err != nil {
+		return nil, err
 	}
-	return result, nil
+
+	// Read all CSV records from UTF8 buffer.
+	csvReader := csv.NewReader(bytes.NewReader(utf8bytes))
+	csvReader.FieldsPerRecord = -1
+	return csvReader.ReadAll()
 }
 
 // Names returns a set of all ancestral surnames.
@@ -409,18 +516,25 @@ func (a *Ancestries) FrequenciesOfNames(names map[string]bool) Frequencies {
 
 // frequenciesOf calculates the Frequencies of the specified set of names.
 // The access function accFunc determines which field of Ancestries should
-// be used for the calculation.
+// be used for the calculation. The Ancestries are counted in a single pass.
 func (a *Ancestries) frequenciesOf(names map[string]bool, accFunc func(Ancestry) map[string]bool) Frequencies {
-	result := make([]Frequency, 0, len(names))
+	// Names are compared in small caps, but returned as given.
+	originals := make(map[string][]string, len(names))
 	for name, _ := range names {
-		count := 0
-		for _, ancestry := range *a {
-			namesInAnc := accFunc(ancestry)
-			if namesInAnc[strings.ToLower(name)] {
-				count++
+		lower := strings.ToLower(name)
+		originals[lower] = append(originals[lower], name)
+	}
+	counts := make(map[string]int, len(names))
+	for _, ancestry := range *a {
+		for name, _ := range accFunc(ancestry) {
+			if _, ok := originals[name]; ok {
+				counts[name]++
 			}
 		}
-		if count > 0 {
+	}
+	result := make([]Frequency, 0, len(counts))
+	for lower, count := range counts {
+		for _, name := range originals[lower] {
 			result = append(result, Frequency{NCousins: count, Name: name})
 		}
 	}
@@ -428,27 +542,30 @@ func (a *Ancestries) frequenciesOf(names map[string]bool, accFunc func(Ancestry)
 }
 
 // Include returns only Ancestries which contain the specified name.
-// The name may be a location or a surname.
+// The name may be a location or a surname. Programs that apply
+// several filters to the same Ancestries should build an Index once
+// and use its filters instead.
 func (a *Ancestries) Include(name string) Ancestries {
-	result := make([]Ancestry, 0, len(*a))
-	for _, ancestry := range *a {
-		if ancestry.Contains(name) {
-			result = append(result, ancestry)
-		}
-	}
-	return result
+	return NewIndex(*a).Include(name)
 }
 
 // Exclude returns only those Ancestries who's ancestral surnames
-// or locations do not contain name.")
+// or locations do not contain name. See Include.
 func (a *Ancestries) Exclude(name string) Ancestries {
-	result := make([]Ancestry, 0, len(*a))
+	return NewIndex(*a).Exclude(name)
+}
+
+// XMatches returns the Ancestries of cousins who also share DNA
+// on the X chromosome and the Ancestries of all other cousins.
+func (a *Ancestries) XMatches() (xmatches, others Ancestries) {
 	for _, ancestry := range *a {
-		if !ancestry.Contains(name) {
-			result = append(result, ancestry)
+		if ancestry.XMatch {
+			xmatches = append(xmatches, ancestry)
+		} else {
+			others = append(others, ancestry)
 		}
 	}
-	return result
+	return xmatches, others
 }
 
 // AncestriesList bundles a list of Ancestries.
@@ -460,24 +577,34 @@ type AncestriesList struct {
 }
 
 // NewAncestriesList loads a list of Ancestries from multiple files
-// in CSV format.
+// in CSV format. The files are loaded concurrently, but the order
+// of the elements is the same as the order of the files.
 // The filenames are given as a comma separated string.
 // namesCol is the column number of the input file which contains the
-// ancestral information.
-func NewAncestriesList(filenames string, namesCol int) (AncestriesList, error) {
+// ancestral information. encoding is the character encoding of the files.
+// Parsed files are stored in cache. cache may be nil.
+func NewAncestriesList(filenames string, namesCol int, encoding string, cache *Cache) (AncestriesList, error) {
 	var result AncestriesList
 	names := strings.Split(filenames, ",")
-	for _, filename := range names {
-		filename = strings.TrimSpace(filename)
-		ancestries, err := NewAncestries(filename, namesCol)
-		if err != nil {
-			return result, err
-		}
-		result.elements = append(result.elements, ancestries)
+	elements := make([]Ancestries, len(names))
+	err := parallelLoad(len(names), func(i int) error {
+		var err error
+		elements[i], err = cache.NewAncestries(strings.TrimSpace(names[i]), namesCol, encoding)
+		return err
+	})
+	if err != nil {
+		return result, err
 	}
+	result.elements = elements
 	return result, nil
 }
 
+// NewAncestriesListOf creates an AncestriesList from Ancestries
+// that are already loaded.
+func NewAncestriesListOf(elements ...Ancestries) AncestriesList {
+	return AncestriesList{elements: elements}
+}
+
 // CommonNames returns the names that occur in
 // all elements of the AncestriesList a.
 func (a *AncestriesList) CommonNames() map[string]bool {
@@ -639,9 +766,9 @@ func (a *AncestriesList) IntersectByLocations() Ancestries {
 // a specific ancestral surname or location.
 type Frequency struct {
 	// Name is the ancestral name or location.
-	Name string
+	Name string `json:"name"`
 	// NCousins shows how many cousins share the same ancestral name or location.
-	NCousins int
+	NCousins int `json:"cousins"`
 }
 
 // Frequencies is a list of Frequency that satisfies the sort.Interface.
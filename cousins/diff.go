@@ -0,0 +1,108 @@
+package cousins
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is a match whose ancestral information differs
+// between two snapshots of a matches file.
+type Change struct {
+	Old Ancestry
+	New Ancestry
+}
+
+// AddedNames returns the surnames that are new in the Change.
+func (c *Change) AddedNames() []string {
+	return sortedKeys(difference(c.New.Names, c.Old.Names))
+}
+
+// RemovedNames returns the surnames that were removed in the Change.
+func (c *Change) RemovedNames() []string {
+	return sortedKeys(difference(c.Old.Names, c.New.Names))
+}
+
+// Diff contains the differences between two snapshots
+// of the same kit's matches.
+type Diff struct {
+	// Added are the matches that occur only in the new snapshot.
+	Added Ancestries
+	// Removed are the matches that occur only in the old snapshot.
+	Removed Ancestries
+	// Changed are the matches with different ancestral information.
+	Changed []Change
+}
+
+// NewDiff compares two snapshots of the same kit's matches.
+// Matches are identified by their name, ignoring case. Ancestries
+// without a match name are identified by their ancestral information,
+// so they can only be added or removed but never changed.
+// The results are in the order of the snapshots.
+func NewDiff(previous, current Ancestries) Diff {
+	var result Diff
+	previousKeys := matchKeys(previous)
+	currentKeys := matchKeys(current)
+	previousByKey := make(map[string]int, len(previous))
+	for i, key := range previousKeys {
+		previousByKey[key] = i
+	}
+	found := make(map[string]bool, len(current))
+	for i, key := range currentKeys {
+		j, ok := previousByKey[key]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, current[i])
+		case previous[j].line != current[i].line:
+			result.Changed = append(result.Changed, Change{Old: previous[j], New: current[i]})
+		}
+		found[key] = true
+	}
+	for i, key := range previousKeys {
+		if !found[key] {
+			result.Removed = append(result.Removed, previous[i])
+		}
+	}
+	return result
+}
+
+// matchKeys returns a key for each Ancestry that identifies
+// the match. Several matches with the same name are numbered
+// in order of occurrence.
+func matchKeys(a Ancestries) []string {
+	result := make([]string, len(a))
+	counts := make(map[string]int)
+	for i, ancestry := range a {
+		key := "match:" + strings.ToLower(strings.TrimSpace(ancestry.Match))
+		if ancestry.Match == "" {
+			key = "line:" + ancestry.line
+		}
+		counts[key]++
+		if counts[key] > 1 {
+			key += "#" + strconv.Itoa(counts[key])
+		}
+		result[i] = key
+	}
+	return result
+}
+
+// difference returns the elements of a that are not contained in b.
+func difference(a, b map[string]bool) map[string]bool {
+	result := make(map[string]bool)
+	for key, _ := range a {
+		if !b[key] {
+			result[key] = true
+		}
+	}
+	return result
+}
+
+// sortedKeys returns the keys of a set in alphabetical order.
+func sortedKeys(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for key, _ := range set {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,146 @@
+package cousins
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Supported character encodings of input files.
+const (
+	EncodingAuto        = "auto"
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingWindows1252 = "windows-1252"
+	EncodingLatin1      = "latin-1"
+)
+
+// encodingAliases maps alternative spellings of encoding names
+// to the supported encodings.
+var encodingAliases = map[string]string{
+	"":             EncodingAuto,
+	"auto":         EncodingAuto,
+	"utf8":         EncodingUTF8,
+	"utf-8":        EncodingUTF8,
+	"utf16le":      EncodingUTF16LE,
+	"utf-16le":     EncodingUTF16LE,
+	"utf16be":      EncodingUTF16BE,
+	"utf-16be":     EncodingUTF16BE,
+	"windows-1252": EncodingWindows1252,
+	"windows1252":  EncodingWindows1252,
+	"cp1252":       EncodingWindows1252,
+	"latin-1":      EncodingLatin1,
+	"latin1":       EncodingLatin1,
+	"iso-8859-1":   EncodingLatin1,
+	"iso8859-1":    EncodingLatin1,
+}
+
+// windows1252 contains the characters for the bytes 0x80 - 0x9F
+// of the Windows-1252 code page. All other bytes are identical
+// to Latin-1. Undefined bytes are mapped to the replacement character.
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// decodeText converts data from the specified character encoding
+// into UTF-8. If encoding is EncodingAuto the encoding is detected
+// from the byte order mark or the content of data.
+// Byte order marks are removed.
+func decodeText(data []byte, encoding string) ([]byte, error) {
+	enc, ok := encodingAliases[strings.ToLower(strings.TrimSpace(encoding))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported character encoding %q", encoding)
+	}
+	if enc == EncodingAuto {
+		enc = DetectEncoding(data)
+	}
+	switch enc {
+	case EncodingUTF8:
+		return bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF}), nil
+	case EncodingUTF16LE:
+		return decodeUTF16(bytes.TrimPrefix(data, []byte{0xFF, 0xFE}), false), nil
+	case EncodingUTF16BE:
+		return decodeUTF16(bytes.TrimPrefix(data, []byte{0xFE, 0xFF}), true), nil
+	case EncodingWindows1252:
+		return decodeSingleByte(data, true), nil
+	default:
+		return decodeSingleByte(data, false), nil
+	}
+}
+
+// DetectEncoding guesses the character encoding of data.
+// Byte order marks are evaluated first. Files without byte order mark
+// are checked for the typical zero bytes of UTF-16 encoded text.
+// Text that is not valid UTF-8 is assumed to be Windows-1252,
+// which is what Excel uses on Western European Windows systems.
+func DetectEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+
+	// Mostly ASCII text encoded as UTF-16 contains a zero byte
+	// in every second position.
+	sample := data
+	if len(sample) > 4096 {
+		sample = sample[:4096]
+	}
+	evenZeros, oddZeros := 0, 0
+	for i, b := range sample {
+		if b == 0 {
+			if i%2 == 0 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+	}
+	half := len(sample) / 2
+	switch {
+	case half > 0 && oddZeros > half/2 && evenZeros*10 < oddZeros:
+		return EncodingUTF16LE
+	case half > 0 && evenZeros > half/2 && oddZeros*10 < evenZeros:
+		return EncodingUTF16BE
+	case utf8.Valid(data):
+		return EncodingUTF8
+	default:
+		return EncodingWindows1252
+	}
+}
+
+// decodeUTF16 converts UTF-16 encoded data into UTF-8.
+// A trailing odd byte is ignored.
+func decodeUTF16(data []byte, bigEndian bool) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// decodeSingleByte converts Latin-1 or Windows-1252 encoded data into UTF-8.
+func decodeSingleByte(data []byte, cp1252 bool) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(data) + len(data)/8)
+	for _, b := range data {
+		switch {
+		case cp1252 && b >= 0x80 && b <= 0x9F:
+			buf.WriteRune(windows1252[b-0x80])
+		default:
+			buf.WriteRune(rune(b))
+		}
+	}
+	return buf.Bytes()
+}
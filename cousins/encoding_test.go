@@ -0,0 +1,56 @@
+package cousins
+
+import "testing"
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"utf-8 bom", []byte{0xEF, 0xBB, 0xBF, 'a', 'b'}, EncodingUTF8},
+		{"utf-16le bom", []byte{0xFF, 0xFE, 'a', 0}, EncodingUTF16LE},
+		{"utf-16be bom", []byte{0xFE, 0xFF, 0, 'a'}, EncodingUTF16BE},
+		{"utf-16le", []byte{'N', 0, 'a', 0, 'm', 0, 'e', 0}, EncodingUTF16LE},
+		{"utf-16be", []byte{0, 'N', 0, 'a', 0, 'm', 0, 'e'}, EncodingUTF16BE},
+		{"utf-8", []byte("Müller"), EncodingUTF8},
+		{"ascii", []byte("Miller"), EncodingUTF8},
+		{"windows-1252", []byte{'M', 0xFC, 'l', 'l', 'e', 'r'}, EncodingWindows1252},
+	}
+	for _, test := range tests {
+		if got := DetectEncoding(test.data); got != test.want {
+			t.Errorf("%s: DetectEncoding(% x) = %s, want %s", test.name, test.data, got, test.want)
+		}
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding string
+		want     string
+	}{
+		{"utf-8 bom", []byte{0xEF, 0xBB, 0xBF, 'M', 0xC3, 0xBC}, "auto", "Mü"},
+		{"utf-16le bom", []byte{0xFF, 0xFE, 'M', 0, 0xFC, 0}, "auto", "Mü"},
+		{"utf-16be bom", []byte{0xFE, 0xFF, 0, 'M', 0, 0xFC}, "auto", "Mü"},
+		{"utf-16le", []byte{'M', 0, 0xFC, 0, 'l', 0, 'l', 0}, "auto", "Müll"},
+		{"utf-16be", []byte{0, 'M', 0, 0xFC, 0, 'l', 0, 'l'}, "auto", "Müll"},
+		{"windows-1252 fallback", []byte{'M', 0xFC, 0x80}, "auto", "Mü€"},
+		{"latin-1", []byte{'M', 0xFC, 0x80}, "ISO-8859-1", "Mü\u0080"},
+		{"windows-1252 undefined", []byte{0x81}, "cp1252", "�"},
+	}
+	for _, test := range tests {
+		got, err := decodeText(test.data, test.encoding)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: decodeText(% x, %s) = %q, want %q", test.name, test.data, test.encoding, got, test.want)
+		}
+	}
+	if _, err := decodeText([]byte("a"), "ebcdic"); err == nil {
+		t.Error("decodeText accepted the unsupported encoding ebcdic")
+	}
+}
@@ -0,0 +1,129 @@
+package cousins
+
+import (
+	"strings"
+)
+
+// Entry is a single entry of a cousin's ancestral information,
+// for example "Schmidt (Hessen, 1780-1850)".
+type Entry struct {
+	// Surname is the ancestral surname in small caps.
+	// It is empty for entries that contain only places.
+	Surname string
+	// Places are the normalized locations of the surname
+	// in alphabetical order.
+	Places []string
+	// Years is the period given for the surname.
+	Years Years
+}
+
+// Prefixes of search terms for entries. A term like
+// "surname:schmidt@hessen" matches only cousins who give
+// Hessen as location of the surname Schmidt.
+const (
+	surnamePrefix = "surname:"
+	placePrefix   = "place:"
+)
+
+// entryKeys returns the search terms that match the entries
+// of the Ancestry: "surname:<surname>", "place:<place>" and
+// "surname:<surname>@<place>".
+func (a *Ancestry) entryKeys() map[string]bool {
+	result := make(map[string]bool)
+	for _, entry := range a.Entries {
+		if entry.Surname != "" {
+			result[surnamePrefix+entry.Surname] = true
+		}
+		for _, place := range entry.Places {
+			result[placePrefix+place] = true
+			if entry.Surname != "" {
+				result[surnamePrefix+entry.Surname+"@"+place] = true
+			}
+		}
+	}
+	return result
+}
+
+// normalizeTerm converts a search term into the form used by
+// Contains and Index. Locations in entry terms are normalized like
+// the locations of Ancestries, so "place:deutschland" becomes
+// "place:germany". "location:" may be used instead of "place:".
+// Tags may be written as "tag:paternal" or "#paternal".
+// Other terms are normalized like single locations.
+func normalizeTerm(term string) string {
+	term = strings.ToLower(strings.TrimSpace(term))
+	switch {
+	case strings.HasPrefix(term, "location:"):
+		return placePrefix + normalizePlace(strings.TrimPrefix(term, "location:"))
+	case strings.HasPrefix(term, placePrefix):
+		return placePrefix + normalizePlace(strings.TrimPrefix(term, placePrefix))
+	case strings.HasPrefix(term, "#"):
+		return tagPrefix + strings.TrimPrefix(term, "#")
+	case strings.HasPrefix(term, tagPrefix):
+		return tagPrefix + strings.TrimPrefix(strings.TrimPrefix(term, tagPrefix), "#")
+	case strings.HasPrefix(term, surnamePrefix):
+		parts := strings.SplitN(strings.TrimPrefix(term, surnamePrefix), "@", 2)
+		result := surnamePrefix + strings.TrimSpace(parts[0])
+		if len(parts) > 1 {
+			result += "@" + normalizePlace(parts[1])
+		}
+		return result
+	}
+	// Plain terms may be locations like "hessen" that are
+	// normalized to "hesse".
+	return normalizePlace(term)
+}
+
+// normalizePlace normalizes a single location. Locations that are
+// expanded into several locations, like "ny" into "new york" and
+// "usa", are replaced by the first one, which is the most specific.
+func normalizePlace(place string) string {
+	place = strings.TrimSpace(expandCounties(place))
+	if clean, ok := cleanTags(place); ok && len(clean) > 0 {
+		return clean[0]
+	}
+	normalized := normalizeTokens(map[string]bool{place: true})
+	if len(normalized) == 1 {
+		for clean, _ := range normalized {
+			return clean
+		}
+	}
+	return place
+}
+
+// SurnamesByLocation returns for each location the Frequencies of the
+// surnames that are given together with the location in the same entry.
+func (a *Ancestries) SurnamesByLocation() map[string]Frequencies {
+	return a.linkedFrequencies(func(surname, place string) (string, string) { return place, surname })
+}
+
+// LocationsBySurname returns for each surname the Frequencies of the
+// locations that are given together with the surname in the same entry.
+func (a *Ancestries) LocationsBySurname() map[string]Frequencies {
+	return a.linkedFrequencies(func(surname, place string) (string, string) { return surname, place })
+}
+
+// linkedFrequencies counts how many cousins link surnames to places.
+// keyFunc selects which of both is the key of the result.
+func (a *Ancestries) linkedFrequencies(keyFunc func(surname, place string) (key, name string)) map[string]Frequencies {
+	counts := make(map[string]map[string]int)
+	for _, ancestry := range *a {
+		for pair, _ := range ancestry.pairs() {
+			parts := strings.SplitN(pair, "@", 2)
+			key, name := keyFunc(parts[0], parts[1])
+			if counts[key] == nil {
+				counts[key] = make(map[string]int)
+			}
+			counts[key][name]++
+		}
+	}
+	result := make(map[string]Frequencies, len(counts))
+	for key, names := range counts {
+		freqs := make(Frequencies, 0, len(names))
+		for name, count := range names {
+			freqs = append(freqs, Frequency{NCousins: count, Name: name})
+		}
+		result[key] = freqs
+	}
+	return result
+}
@@ -0,0 +1,362 @@
+package cousins
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// format describes the matches file of a DNA testing company
+// or genealogy service.
+type format struct {
+	// name is the name of the format.
+	name string
+	// detect reports if a file with the given columns is in this format.
+	detect func(cols columns) bool
+	// read creates Ancestries from the rows of a file without
+	// the column captions. namesCol is only used by formats
+	// that have no column captions for the ancestral surnames.
+	read func(cols columns, rows [][]string, namesCol int) (Ancestries, error)
+}
+
+// formats contains all supported file formats in the order
+// they are tried. Family Finder is detected first, because its
+// files share captions like "Shared Centimorgans" with other
+// formats. It is also the fallback format and must be the last entry.
+var formats = []format{
+	{name: "Family Finder", detect: detectFamilyFinder, read: readFamilyFinder},
+	{name: "23andMe", detect: detect23andMe, read: read23andMe},
+	{name: "MyHeritage", detect: detectMyHeritage, read: readMyHeritage},
+	{name: "AncestryDNA", detect: detectAncestryDNA, read: readAncestryDNA},
+	{name: "GEDmatch", detect: detectGEDmatch, read: readGEDmatch},
+	{name: "Tree surnames", detect: detectTreeSurnames, read: readTreeSurnames},
+	{name: "Family Finder", detect: func(cols columns) bool { return true }, read: readFamilyFinder},
+}
+
+// detectFormat returns the format for a file with the given
+// column captions.
+func detectFormat(header []string) format {
+	cols := newColumns(header)
+	for _, f := range formats {
+		if f.detect(cols) {
+			return f
+		}
+	}
+	return formats[len(formats)-1]
+}
+
+// columns maps column captions in small caps to column numbers.
+type columns map[string]int
+
+func newColumns(header []string) columns {
+	result := make(columns)
+	for i, caption := range header {
+		caption = strings.ToLower(strings.TrimSpace(caption))
+		if _, exists := result[caption]; !exists {
+			result[caption] = i
+		}
+	}
+	return result
+}
+
+// index returns the number of the first column that has one of
+// the given captions or -1 if no such column exists.
+func (c columns) index(captions ...string) int {
+	for _, caption := range captions {
+		if i, ok := c[caption]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// has reports if one of the given captions exists.
+func (c columns) has(captions ...string) bool {
+	return c.index(captions...) >= 0
+}
+
+// matching returns the numbers of all columns whose captions
+// contain part in ascending order.
+func (c columns) matching(part string) []int {
+	var result []int
+	for caption, i := range c {
+		if strings.Contains(caption, part) {
+			result = append(result, i)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+// field returns the trimmed field number i of row or an empty
+// string if the field does not exist.
+func field(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// newAncestryFromLists creates an Ancestry from separate lists of
+// surnames and locations. This is used for formats that do not link
+// surnames to locations.
+func newAncestryFromLists(names, locations []string) Ancestry {
+	var entries []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			entries = append(entries, name)
+		}
+	}
+	var places []string
+	for _, loc := range locations {
+		loc = strings.TrimSpace(loc)
+		if loc != "" {
+			places = append(places, loc)
+		}
+	}
+	if len(places) > 0 {
+		// An entry in braces holds the locations.
+		entries = append(entries, "("+strings.Join(places, ", ")+")")
+	}
+	return newAncestry(strings.Join(entries, " / "), func(entry string) Entry {
+		// Family Finder treats entries starting with a brace as
+		// surnames, but here such an entry contains only locations.
+		entry = strings.TrimSpace(entry)
+		if strings.HasPrefix(entry, "(") {
+			places, years := parsePlaces(entry)
+			return Entry{Places: places, Years: years}
+		}
+		return parseEntry(entry)
+	})
+}
+
+// splitList splits a list of names separated by commas or semicolons.
+func splitList(list string) []string {
+	return strings.FieldsFunc(list, func(c rune) bool { return c == ',' || c == ';' })
+}
+
+// detectFamilyFinder detects Family Tree DNA's Family Finder matches
+// files by their captions "Full Name" and "Ancestral Surnames".
+func detectFamilyFinder(cols columns) bool {
+	return cols.has("full name") && cols.has("ancestral surnames")
+}
+
+// readFamilyFinder reads Family Tree DNA's Family Finder matches files.
+func readFamilyFinder(cols columns, rows [][]string, namesCol int) (Ancestries, error) {
+	if namesCol < 0 || len(rows) > 0 && namesCol >= len(rows[0]) {
+		return nil, fmt.Errorf("column %d for ancestral surnames does not exist", namesCol+1)
+	}
+	matchCol := cols.index("full name", "name")
+	dateCol := cols.index("match date")
+	yCol := cols.index("y-dna haplogroup")
+	mtCol := cols.index("mtdna haplogroup")
+	xCol := cols.index("x-match", "x match")
+	notesCol := cols.index("notes", "note")
+	cmCol := cols.index("shared cm", "shared dna", "shared centimorgans")
+	relCol := cols.index("suggested relationship", "relationship range")
+	result := make(Ancestries, len(rows))
+	parallelFor(len(rows), func(i int) {
+		result[i] = NewAncestry(field(rows[i], namesCol))
+		result[i].Match = field(rows[i], matchCol)
+		result[i].MatchDate = parseMatchDate(field(rows[i], dateCol))
+		result[i].YHaplogroup = field(rows[i], yCol)
+		result[i].MtHaplogroup = field(rows[i], mtCol)
+		result[i].XMatch = isYes(field(rows[i], xCol))
+		result[i].setNotes(field(rows[i], notesCol))
+		result[i].SharedCM = parseCM(field(rows[i], cmCol))
+		result[i].Relationship = field(rows[i], relCol)
+	})
+	return result, nil
+}
+
+// parseCM parses a length in centimorgans like "85.5" or "1,234 cM".
+// It returns 0 if the value is not a valid length.
+func parseCM(value string) float64 {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.ToLower(value), "cm"))
+	cm, err := strconv.ParseFloat(strings.Replace(value, ",", "", -1), 64)
+	if err != nil || cm < 0 {
+		return 0
+	}
+	return cm
+}
+
+// isYes reports whether a field contains a mark like "X" or "Yes".
+func isYes(value string) bool {
+	switch strings.ToLower(value) {
+	case "", "no", "n", "false", "0", "-":
+		return false
+	}
+	return true
+}
+
+// detect23andMe detects 23andMe DNA Relatives downloads.
+func detect23andMe(cols columns) bool {
+	return cols.has("family surnames") || cols.has("display name") && cols.has("percent dna shared")
+}
+
+// cmPerPercent converts the percentage of shared DNA given by
+// 23andMe into centimorgans.
+const cmPerPercent = 74
+
+// read23andMe reads 23andMe DNA Relatives downloads. The locations are
+// taken from the family locations and the grandparents' birth countries.
+func read23andMe(cols columns, rows [][]string, namesCol int) (Ancestries, error) {
+	matchCol := cols.index("display name", "name")
+	namesIdx := cols.index("family surnames", "surnames")
+	locCols := []int{cols.index("family locations")}
+	locCols = append(locCols, cols.matching("birth country")...)
+	dateCol := cols.index("date added", "date of match")
+	yCol := cols.index("paternal haplogroup", "y-dna haplogroup")
+	mtCol := cols.index("maternal haplogroup", "mtdna haplogroup")
+	notesCol := cols.index("notes", "note")
+	cmCol := cols.index("total cm", "shared cm")
+	percentCol := cols.index("percent dna shared")
+	relCol := cols.index("predicted relationship")
+	result := make(Ancestries, len(rows))
+	parallelFor(len(rows), func(i int) {
+		var locations []string
+		for _, col := range locCols {
+			locations = append(locations, splitList(field(rows[i], col))...)
+		}
+		result[i] = newAncestryFromLists(splitList(field(rows[i], namesIdx)), locations)
+		result[i].Match = field(rows[i], matchCol)
+		result[i].MatchDate = parseMatchDate(field(rows[i], dateCol))
+		result[i].YHaplogroup = field(rows[i], yCol)
+		result[i].MtHaplogroup = field(rows[i], mtCol)
+		result[i].setNotes(field(rows[i], notesCol))
+		result[i].SharedCM = parseCM(field(rows[i], cmCol))
+		if result[i].SharedCM == 0 {
+			percent := parseCM(strings.TrimSuffix(field(rows[i], percentCol), "%"))
+			result[i].SharedCM = percent * cmPerPercent
+		}
+		result[i].Relationship = field(rows[i], relCol)
+	})
+	return result, nil
+}
+
+// detectMyHeritage detects MyHeritage DNA match exports.
+func detectMyHeritage(cols columns) bool {
+	return cols.has("dna match id") || cols.has("estimated relationship") && cols.has("shared dna")
+}
+
+// readMyHeritage reads MyHeritage DNA match exports.
+func readMyHeritage(cols columns, rows [][]string, namesCol int) (Ancestries, error) {
+	matchCol := cols.index("name", "match name")
+	namesIdx := cols.index("ancestral surnames", "shared ancestral surnames", "surnames")
+	locIdx := cols.index("ancestral places", "shared ancestral places", "places")
+	dateCol := cols.index("date of match", "match date", "date added")
+	notesCol := cols.index("notes", "note")
+	cmCol := cols.index("shared dna", "total cm", "shared cm")
+	relCol := cols.index("estimated relationship")
+	result := make(Ancestries, len(rows))
+	parallelFor(len(rows), func(i int) {
+		result[i] = newAncestryFromLists(splitList(field(rows[i], namesIdx)), splitList(field(rows[i], locIdx)))
+		result[i].Match = field(rows[i], matchCol)
+		result[i].MatchDate = parseMatchDate(field(rows[i], dateCol))
+		result[i].setNotes(field(rows[i], notesCol))
+		result[i].SharedCM = parseCM(field(rows[i], cmCol))
+		result[i].Relationship = field(rows[i], relCol)
+	})
+	return result, nil
+}
+
+// detectAncestryDNA detects AncestryDNA match lists as exported
+// by third party tools. Besides the shared centimorgans they contain
+// captions that Family Finder files do not have, like the test ID.
+func detectAncestryDNA(cols columns) bool {
+	return cols.has("sharedcentimorgans", "shared centimorgans") &&
+		cols.has("testid", "test id", "matchid", "match id", "sharedsegment", "shared segments", "predictedrelationship", "predicted relationship")
+}
+
+// readAncestryDNA reads AncestryDNA match lists.
+func readAncestryDNA(cols columns, rows [][]string, namesCol int) (Ancestries, error) {
+	matchCol := cols.index("name", "match name", "display name")
+	namesIdx := cols.index("surnames", "ancestral surnames")
+	locIdx := cols.index("places", "birth places", "birthplaces")
+	dateCol := cols.index("match date", "date", "date added")
+	notesCol := cols.index("notes", "note")
+	cmCol := cols.index("sharedcentimorgans", "shared centimorgans")
+	relCol := cols.index("predictedrelationship", "predicted relationship", "relationship range")
+	result := make(Ancestries, len(rows))
+	parallelFor(len(rows), func(i int) {
+		result[i] = newAncestryFromLists(splitList(field(rows[i], namesIdx)), splitList(field(rows[i], locIdx)))
+		result[i].Match = field(rows[i], matchCol)
+		result[i].MatchDate = parseMatchDate(field(rows[i], dateCol))
+		result[i].setNotes(field(rows[i], notesCol))
+		result[i].SharedCM = parseCM(field(rows[i], cmCol))
+		result[i].Relationship = field(rows[i], relCol)
+	})
+	return result, nil
+}
+
+// detectGEDmatch detects GEDmatch one-to-many match lists.
+func detectGEDmatch(cols columns) bool {
+	return cols.has("kit") && cols.has("total cm")
+}
+
+// readGEDmatch reads GEDmatch one-to-many match lists. These lists
+// contain no ancestral information but are useful in combination
+// with family trees.
+func readGEDmatch(cols columns, rows [][]string, namesCol int) (Ancestries, error) {
+	matchCol := cols.index("name")
+	namesIdx := cols.index("surnames")
+	cmCol := cols.index("total cm")
+	result := make(Ancestries, len(rows))
+	parallelFor(len(rows), func(i int) {
+		result[i] = newAncestryFromLists(splitList(field(rows[i], namesIdx)), nil)
+		result[i].Match = field(rows[i], matchCol)
+		result[i].SharedCM = parseCM(field(rows[i], cmCol))
+	})
+	return result, nil
+}
+
+// detectTreeSurnames detects exports of the surnames in the family
+// trees of matches. Each row contains one ancestor of a match.
+func detectTreeSurnames(cols columns) bool {
+	return cols.has("surname") && cols.has("match name", "matchname", "match")
+}
+
+// readTreeSurnames reads exports of the surnames in the family trees
+// of matches. All rows of a match are combined into a single Ancestry.
+func readTreeSurnames(cols columns, rows [][]string, namesCol int) (Ancestries, error) {
+	matchCol := cols.index("match name", "matchname", "match")
+	surnameCol := cols.index("surname")
+	placeCols := []int{
+		cols.index("birth place", "birthplace", "place"),
+		cols.index("death place", "deathplace"),
+	}
+
+	// Collect the entries of each match in order of appearance.
+	var matches []string
+	entries := make(map[string][]string)
+	for _, row := range rows {
+		match := field(row, matchCol)
+		surname := field(row, surnameCol)
+		if surname == "" {
+			continue
+		}
+		var places []string
+		for _, col := range placeCols {
+			if place := field(row, col); place != "" {
+				places = append(places, place)
+			}
+		}
+		entry := surname
+		if len(places) > 0 {
+			entry += " (" + strings.Join(places, ", ") + ")"
+		}
+		if _, exists := entries[match]; !exists {
+			matches = append(matches, match)
+		}
+		entries[match] = append(entries[match], entry)
+	}
+
+	result := make(Ancestries, len(matches))
+	parallelFor(len(matches), func(i int) {
+		result[i] = NewAncestry(strings.Join(entries[matches[i]], " / "))
+		result[i].Match = matches[i]
+	})
+	return result, nil
+}
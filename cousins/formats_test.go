@@ -0,0 +1,74 @@
+package cousins
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEntryStartingWithBrace(t *testing.T) {
+	// Family Finder: an entry starting with a brace is a surname.
+	a := NewAncestry("(Schmidt) / Meier (Hessen)")
+	if !a.Names["schmidt"] || !a.Names["meier"] {
+		t.Errorf("names = %v, want schmidt and meier", a.Names)
+	}
+	if a.Locations["schmidt"] {
+		t.Errorf("schmidt must not be a location: %v", a.Locations)
+	}
+}
+
+func TestNewAncestryFromLists(t *testing.T) {
+	a := newAncestryFromLists([]string{"Smith", "Jones"}, []string{"Ohio", "Virginia"})
+	want := map[string]bool{"smith": true, "jones": true}
+	if !reflect.DeepEqual(a.Names, want) {
+		t.Errorf("names = %v, want %v", a.Names, want)
+	}
+	for _, loc := range []string{"ohio", "virginia", "usa"} {
+		if !a.Locations[loc] {
+			t.Errorf("location %q missing in %v", loc, a.Locations)
+		}
+	}
+	last := a.Entries[len(a.Entries)-1]
+	if last.Surname != "" {
+		t.Errorf("surname of location entry = %q, want empty", last.Surname)
+	}
+}
+
+func TestDetectFamilyFinder(t *testing.T) {
+	// Header of a Family Finder download with the column Shared Centimorgans.
+	data := "Full Name,First Name,Middle Name,Last Name,Match Date,Relationship Range,Suggested Relationship,Shared Centimorgans,Longest Block,Known Relationship,E-mail,Ancestral Surnames,Y-DNA Haplogroup,mtDNA Haplogroup,Notes,Matching Bucket,X-Match\r\n" +
+		"Anna Meier,Anna,,Meier,9/20/2014,2nd Cousin - 4th Cousin,3rd Cousin,85.5,30.1,,a@example.com,\"Schmidt (Germany)\",R-M269,H1a,,Paternal,X\r\n"
+	ancestries, err := ParseAncestries([]byte(data), 11, "utf-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ancestries) != 1 {
+		t.Fatalf("got %d ancestries, want 1", len(ancestries))
+	}
+	a := ancestries[0]
+	if !a.Names["schmidt"] || a.Names["germany"] || !a.Locations["germany"] {
+		t.Errorf("names = %v, locations = %v, want surname schmidt and location germany", a.Names, a.Locations)
+	}
+	if a.YHaplogroup != "R-M269" || a.MtHaplogroup != "H1a" || !a.XMatch {
+		t.Errorf("haplogroups %q, %q and X-match %v are missing", a.YHaplogroup, a.MtHaplogroup, a.XMatch)
+	}
+	if a.SharedCM != 85.5 {
+		t.Errorf("shared cM = %v, want 85.5", a.SharedCM)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"Full Name,Match Date,Shared Centimorgans,Ancestral Surnames", "Family Finder"},
+		{"testid,matchid,name,range,sharedCentimorgans,sharedSegment,surnames", "AncestryDNA"},
+		{"Name,Shared Centimorgans,Notes", "Family Finder"},
+	}
+	for _, test := range tests {
+		if got := detectFormat(strings.Split(test.header, ",")).name; got != test.want {
+			t.Errorf("detectFormat(%q) = %s, want %s", test.header, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package cousins
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Tree is a family tree read from a GEDCOM file.
+// It supports GEDCOM versions 5.5.1 and 7.0.
+type Tree struct {
+	// persons are the individuals of the tree by cross reference.
+	persons map[string]*person
+	// order contains the cross references of all individuals
+	// in the order of the file.
+	order []string
+	// families are the families of the tree by cross reference.
+	families map[string]*family
+}
+
+// person is an individual of a family tree.
+type person struct {
+	given      string
+	surname    string
+	birthPlace string
+	deathPlace string
+	birthDate  string
+	deathDate  string
+	// famc are the families in which the person is a child.
+	famc []string
+}
+
+// name returns the full name of the person.
+func (p *person) name() string {
+	return strings.TrimSpace(p.given + " " + p.surname)
+}
+
+// family is a family of a family tree.
+type family struct {
+	husband string
+	wife    string
+}
+
+// gedcomLine is a single line of a GEDCOM file.
+type gedcomLine struct {
+	level int
+	xref  string
+	tag   string
+	value string
+}
+
+// parseGedcomLine parses a line of the form "level [@xref@] tag [value]".
+func parseGedcomLine(line string) (gedcomLine, error) {
+	var result gedcomLine
+	fields := strings.SplitN(strings.TrimSpace(line), " ", 2)
+	level, err := strconv.Atoi(fields[0])
+	if err != nil || level < 0 || len(fields) < 2 {
+		return result, fmt.Errorf("invalid GEDCOM line %q", line)
+	}
+	result.level = level
+	rest := fields[1]
+	if strings.HasPrefix(rest, "@") {
+		fields = strings.SplitN(rest, " ", 2)
+		result.xref = fields[0]
+		if len(fields) < 2 {
+			return result, fmt.Errorf("invalid GEDCOM line %q", line)
+		}
+		rest = fields[1]
+	}
+	fields = strings.SplitN(rest, " ", 2)
+	result.tag = strings.ToUpper(fields[0])
+	if len(fields) > 1 {
+		result.value = fields[1]
+	}
+	return result, nil
+}
+
+// ReadGEDCOM reads a family tree from a file in GEDCOM format.
+// encoding is the character encoding of the file.
+// If encoding is "auto" or empty, the encoding is detected automatically.
+// Files in the ANSEL character encoding of GEDCOM 5.5.1 are only
+// supported if they contain no characters besides ASCII.
+func ReadGEDCOM(filename string, encoding string) (*Tree, error) {
+	inbytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if encodingAliases[strings.ToLower(strings.TrimSpace(encoding))] == EncodingAuto &&
+		gedcomCharset(inbytes) == "ANSEL" && !isASCII(inbytes) {
+		return nil, fmt.Errorf("%s uses the ANSEL character encoding, which is not supported, please export the tree in UTF-8", filename)
+	}
+	utf8bytes, err := decodeText(inbytes, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &Tree{persons: make(map[string]*person), families: make(map[string]*family)}
+	var (
+		// Current record, only one of them is not nil.
+		indi *person
+		fam  *family
+		// Tags of the current structures by level.
+		tags [100]string
+		// place points to the place that is currently read,
+		// so that CONC and CONT lines can be appended.
+		place *string
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(utf8bytes))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		line, err := parseGedcomLine(text)
+		if err != nil {
+			return nil, err
+		}
+		if line.level >= len(tags) {
+			continue
+		}
+		tags[line.level] = line.tag
+		if line.tag != "CONC" && line.tag != "CONT" {
+			place = nil
+		}
+
+		switch {
+		case line.level == 0:
+			indi, fam = nil, nil
+			switch line.tag {
+			case "INDI":
+				indi = &person{}
+				tree.persons[line.xref] = indi
+				tree.order = append(tree.order, line.xref)
+			case "FAM":
+				fam = &family{}
+				tree.families[line.xref] = fam
+			}
+		case indi != nil:
+			switch {
+			case line.level == 1 && line.tag == "NAME" && indi.surname == "" && indi.given == "":
+				indi.given, indi.surname = splitGedcomName(line.value)
+			case line.level == 2 && tags[1] == "NAME" && line.tag == "SURN":
+				indi.surname = strings.TrimSpace(line.value)
+			case line.level == 1 && line.tag == "FAMC":
+				indi.famc = append(indi.famc, strings.TrimSpace(line.value))
+			case line.level == 2 && line.tag == "PLAC":
+				switch tags[1] {
+				case "BIRT", "CHR", "BAPM":
+					if indi.birthPlace == "" {
+						indi.birthPlace = line.value
+						place = &indi.birthPlace
+					}
+				case "DEAT", "BURI":
+					if indi.deathPlace == "" {
+						indi.deathPlace = line.value
+						place = &indi.deathPlace
+					}
+				}
+			case line.level == 2 && line.tag == "DATE":
+				switch tags[1] {
+				case "BIRT", "CHR", "BAPM":
+					if indi.birthDate == "" {
+						indi.birthDate = line.value
+					}
+				case "DEAT", "BURI":
+					if indi.deathDate == "" {
+						indi.deathDate = line.value
+					}
+				}
+			case line.tag == "CONC" && place != nil:
+				*place += line.value
+			case line.tag == "CONT" && place != nil:
+				*place += ", " + line.value
+			}
+		case fam != nil && line.level == 1:
+			switch line.tag {
+			case "HUSB":
+				fam.husband = strings.TrimSpace(line.value)
+			case "WIFE":
+				fam.wife = strings.TrimSpace(line.value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(tree.order) == 0 {
+		return nil, errors.New("no individuals found in GEDCOM file")
+	}
+	return tree, nil
+}
+
+// gedcomCharset returns the character encoding declared by the CHAR
+// line in the header of a GEDCOM file in capital letters. It returns
+// an empty string if the header contains no CHAR line.
+func gedcomCharset(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for first := true; scanner.Scan(); first = false {
+		line, err := parseGedcomLine(scanner.Text())
+		switch {
+		case err != nil:
+			continue
+		case line.level == 0 && !first:
+			// End of the header.
+			return ""
+		case line.level == 1 && line.tag == "CHAR":
+			return strings.ToUpper(strings.TrimSpace(line.value))
+		}
+	}
+	return ""
+}
+
+// isASCII reports whether data contains only ASCII characters.
+func isASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// splitGedcomName splits a GEDCOM name like "John /Smith/"
+// into given name and surname.
+func splitGedcomName(name string) (given, surname string) {
+	start := strings.Index(name, "/")
+	if start < 0 {
+		return strings.TrimSpace(name), ""
+	}
+	end := strings.Index(name[start+1:], "/")
+	if end < 0 {
+		return strings.TrimSpace(name[:start]), strings.TrimSpace(name[start+1:])
+	}
+	given = strings.TrimSpace(name[:start] + " " + name[start+1+end+1:])
+	surname = strings.TrimSpace(name[start+1 : start+1+end])
+	return given, surname
+}
+
+// find returns the cross reference of the individual specified
+// by root. root may be a cross reference like "@I1@" or the full
+// name of an individual. If root is empty the first individual
+// of the file is returned.
+func (t *Tree) find(root string) (string, error) {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return t.order[0], nil
+	}
+	if _, ok := t.persons[root]; ok {
+		return root, nil
+	}
+	for _, xref := range t.order {
+		if strings.EqualFold(t.persons[xref].name(), root) {
+			return xref, nil
+		}
+	}
+	return "", fmt.Errorf("person %s not found in tree", root)
+}
+
+// RootName returns the full name of the individual specified by root.
+// See Ancestry for the meaning of root.
+func (t *Tree) RootName(root string) (string, error) {
+	xref, err := t.find(root)
+	if err != nil {
+		return "", err
+	}
+	return t.persons[xref].name(), nil
+}
+
+// Ancestry creates an Ancestry from the surnames and places of
+// the individual specified by root and all of its ancestors.
+// root may be a cross reference like "@I1@" or the full name of
+// an individual. If root is empty the first individual in the file
+// is used. The Match of the result is the name of the root person.
+func (t *Tree) Ancestry(root string) (Ancestry, error) {
+	xref, err := t.find(root)
+	if err != nil {
+		return Ancestry{}, err
+	}
+
+	// Walk through the ancestors, avoiding loops in broken trees.
+	var entries []string
+	visited := make(map[string]bool)
+	queue := []string{xref}
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		p, ok := t.persons[ref]
+		if !ok || visited[ref] {
+			continue
+		}
+		visited[ref] = true
+		if entry := p.entry(); entry != "" {
+			entries = append(entries, entry)
+		}
+		for _, famc := range p.famc {
+			if fam, ok := t.families[famc]; ok {
+				queue = append(queue, fam.husband, fam.wife)
+			}
+		}
+	}
+
+	result := NewAncestry(strings.Join(entries, " / "))
+	result.Match = t.persons[xref].name()
+	return result, nil
+}
+
+// entry returns the person's surname, places and years in the format
+// of the Family Finder matches file: surname (birth place, death place, years).
+func (p *person) entry() string {
+	// Slashes and braces would break the entry format.
+	clean := strings.NewReplacer("/", " ", "(", " ", ")", " ")
+	surname := strings.TrimSpace(clean.Replace(p.surname))
+	if surname == "" {
+		return ""
+	}
+	var places []string
+	for _, place := range []string{p.birthPlace, p.deathPlace} {
+		place = strings.TrimSpace(clean.Replace(place))
+		if place != "" {
+			places = append(places, place)
+		}
+	}
+	// Only the years of the dates are used.
+	_, years := extractYears(p.birthDate)
+	_, deathYears := extractYears(p.deathDate)
+	if years = years.Union(deathYears); !years.IsZero() {
+		places = append(places, years.String())
+	}
+	if len(places) == 0 {
+		return surname
+	}
+	return surname + " (" + strings.Join(places, ", ") + ")"
+}
+
+// Merge adds the ancestral information of b to a.
+// Information about the match, like Match and MatchDate, is kept.
+func (a *Ancestry) Merge(b Ancestry) {
+	var merged Ancestry
+	switch {
+	case a.line == "":
+		merged = NewAncestry(b.line)
+	case b.line != "":
+		merged = NewAncestry(a.line + " / " + b.line)
+	default:
+		return
+	}
+	a.line, a.Words, a.Tokens = merged.line, merged.Words, merged.Tokens
+	a.Names, a.Locations = merged.Names, merged.Locations
+	a.Entries = merged.Entries
+}
+
+// Link merges the tree into all Ancestries whose Match is equal
+// to the Match of tree, ignoring case. It returns the number of
+// Ancestries that were linked.
+func (a *Ancestries) Link(tree Ancestry) int {
+	count := 0
+	for i := range *a {
+		if (*a)[i].Match != "" && strings.EqualFold((*a)[i].Match, tree.Match) {
+			(*a)[i].Merge(tree)
+			count++
+		}
+	}
+	return count
+}
+
+// Link merges the tree into all elements of the AncestriesList.
+// See Ancestries.Link.
+func (a *AncestriesList) Link(tree Ancestry) int {
+	count := 0
+	for i := range a.elements {
+		count += a.elements[i].Link(tree)
+	}
+	return count
+}
+
+// TreeLink links a match to the family tree of the match.
+type TreeLink struct {
+	// Match is the name of the match as given in the matches file.
+	Match string
+	// File is the name of the GEDCOM file.
+	File string
+	// Root specifies the match in the tree, either by cross reference
+	// or by name. If it is empty, the first individual is used.
+	Root string
+}
+
+// ReadTreeLinks reads a mapping file in CSV format that links
+// matches to GEDCOM files. Each row contains the name of the match,
+// the GEDCOM filename and optionally the root person in the tree.
+// A first row with the caption "Match" is skipped.
+func ReadTreeLinks(filename string, encoding string) ([]TreeLink, error) {
+	records, err := readCSV(filename, encoding)
+	if err != nil {
+		return nil, err
+	}
+	var result []TreeLink
+	for i, row := range records {
+		if i == 0 && strings.EqualFold(field(row, 0), "match") {
+			continue
+		}
+		link := TreeLink{Match: field(row, 0), File: field(row, 1), Root: field(row, 2)}
+		if link.Match == "" || link.File == "" {
+			return nil, fmt.Errorf("line %d of %s: match name and GEDCOM file required", i+1, filename)
+		}
+		result = append(result, link)
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package cousins
+
+import "testing"
+
+func TestParseGedcomLineInvalid(t *testing.T) {
+	for _, line := range []string{"-1 FOO bar", "x INDI", "0"} {
+		if _, err := parseGedcomLine(line); err == nil {
+			t.Errorf("parseGedcomLine(%q) returned no error", line)
+		}
+	}
+	line, err := parseGedcomLine("0 @I1@ INDI")
+	if err != nil || line.level != 0 || line.xref != "@I1@" || line.tag != "INDI" {
+		t.Errorf("parseGedcomLine = %+v, %v", line, err)
+	}
+}
+
+const testGedcom = "0 HEAD\r\n1 GEDC\r\n2 VERS 5.5.1\r\n1 CHAR UTF-8\r\n" +
+	"0 @I1@ INDI\r\n1 NAME John /Smith/\r\n1 BIRT\r\n2 DATE 1950\r\n2 PLAC Richmond, Virginia, USA\r\n1 FAMC @F1@\r\n" +
+	"0 @I2@ INDI\r\n1 NAME Peter /Smith/\r\n1 BIRT\r\n2 DATE 12 MAR 1921\r\n2 PLAC Marburg, Hes\r\n3 CONC sen, Germany\r\n1 FAMS @F1@\r\n" +
+	"0 @I3@ INDI\r\n1 NAME Maria /Fischer/\r\n1 DEAT\r\n2 DATE 1980\r\n2 PLAC Cork, Ireland\r\n1 FAMS @F1@\r\n" +
+	"0 @F1@ FAM\r\n1 HUSB @I2@\r\n1 WIFE @I3@\r\n1 CHIL @I1@\r\n0 TRLR\r\n"
+
+func TestReadGEDCOM(t *testing.T) {
+	tree, err := ReadGEDCOM(writeTempFile(t, "tree.ged", testGedcom), "auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := tree.RootName("")
+	if err != nil || name != "John Smith" {
+		t.Errorf("RootName = %q, %v, want John Smith", name, err)
+	}
+	a, err := tree.Ancestry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Match != "John Smith" || !a.Names["smith"] || !a.Names["fischer"] {
+		t.Errorf("match = %q, names = %v, want John Smith with smith and fischer", a.Match, a.Names)
+	}
+	for _, loc := range []string{"virginia", "usa", "hesse", "germany", "cork", "ireland"} {
+		if !a.Locations[loc] {
+			t.Errorf("location %q missing in %v", loc, a.Locations)
+		}
+	}
+	years := make(map[string]Years)
+	for _, entry := range a.Entries {
+		years[entry.Surname] = years[entry.Surname].Union(entry.Years)
+	}
+	if years["smith"] != (Years{1921, 1950}) || years["fischer"] != (Years{1980, 1980}) {
+		t.Errorf("years = %v, want smith 1921-1950 and fischer 1980", years)
+	}
+
+	// The root person can be given by name or cross reference.
+	a, err = tree.Ancestry("@I2@")
+	if err != nil || a.Match != "Peter Smith" || a.Names["fischer"] {
+		t.Errorf("Ancestry(@I2@) = %q with names %v, %v", a.Match, a.Names, err)
+	}
+	if _, err := tree.Ancestry("Nobody"); err == nil {
+		t.Error("Ancestry(Nobody) returned no error")
+	}
+}
+
+func TestReadGEDCOMAnsel(t *testing.T) {
+	ansel := "0 HEAD\r\n1 CHAR ANSEL\r\n0 @I1@ INDI\r\n1 NAME Anna /M\xe8uller/\r\n0 TRLR\r\n"
+	if _, err := ReadGEDCOM(writeTempFile(t, "ansel.ged", ansel), "auto"); err == nil {
+		t.Error("ANSEL file with diacritics was read without error")
+	}
+	ascii := "0 HEAD\r\n1 CHAR ANSEL\r\n0 @I1@ INDI\r\n1 NAME Anna /Miller/\r\n0 TRLR\r\n"
+	if _, err := ReadGEDCOM(writeTempFile(t, "ascii.ged", ascii), "auto"); err != nil {
+		t.Errorf("ASCII only ANSEL file: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package cousins
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MatchHaplogroup reports whether haplogroup matches one of the
+// comma separated patterns. Patterns are compared ignoring case.
+// A pattern ending with "*" matches the haplogroup and all its
+// subclades, for example "H*" matches H, H1 and H1a1 but not HV.
+// See isSubclade. Other patterns must match exactly. An empty haplogroup never matches.
+func MatchHaplogroup(haplogroup, patterns string) bool {
+	haplogroup = strings.ToLower(strings.TrimSpace(haplogroup))
+	if haplogroup == "" {
+		return false
+	}
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "":
+			continue
+		case strings.HasSuffix(pattern, "*"):
+			if isSubclade(haplogroup, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		case haplogroup == pattern:
+			return true
+		}
+	}
+	return false
+}
+
+// isSubclade reports whether haplogroup is the haplogroup parent
+// or one of its subclades. Following the ISOGG naming rules letters
+// and digits alternate in the names of subclades, so the part after
+// parent must start with a digit if parent ends with a letter and
+// with a letter if parent ends with a digit. HV is not a subclade
+// of H and H10 is not a subclade of H1.
+func isSubclade(haplogroup, parent string) bool {
+	if !strings.HasPrefix(haplogroup, parent) {
+		return false
+	}
+	if parent == "" || len(haplogroup) == len(parent) {
+		return true
+	}
+	last, _ := utf8.DecodeLastRuneInString(parent)
+	next, _ := utf8.DecodeRuneInString(haplogroup[len(parent):])
+	switch {
+	case unicode.IsLetter(last):
+		return !unicode.IsLetter(next)
+	case unicode.IsDigit(last):
+		return !unicode.IsDigit(next)
+	}
+	return true
+}
+
+// Haplogroups returns the Ancestries whose Y-DNA haplogroup matches
+// yPatterns and whose mtDNA haplogroup matches mtPatterns.
+// Empty patterns are not evaluated. See MatchHaplogroup.
+func (a *Ancestries) Haplogroups(yPatterns, mtPatterns string) Ancestries {
+	result := make(Ancestries, 0, len(*a))
+	for _, ancestry := range *a {
+		if ancestry.MatchHaplogroups(yPatterns, mtPatterns) {
+			result = append(result, ancestry)
+		}
+	}
+	return result
+}
+
+// MatchHaplogroups reports whether the haplogroups of the cousin
+// match yPatterns and mtPatterns. See Haplogroups.
+func (a *Ancestry) MatchHaplogroups(yPatterns, mtPatterns string) bool {
+	if yPatterns != "" && !MatchHaplogroup(a.YHaplogroup, yPatterns) {
+		return false
+	}
+	return mtPatterns == "" || MatchHaplogroup(a.MtHaplogroup, mtPatterns)
+}
+
+// YHaplogroupFrequencies determines how many cousins belong to
+// which Y-DNA haplogroup. Cousins without haplogroup are not counted.
+func (a *Ancestries) YHaplogroupFrequencies() Frequencies {
+	return a.haplogroupFrequencies(func(anc Ancestry) string { return anc.YHaplogroup })
+}
+
+// MtHaplogroupFrequencies determines how many cousins belong to
+// which mtDNA haplogroup. Cousins without haplogroup are not counted.
+func (a *Ancestries) MtHaplogroupFrequencies() Frequencies {
+	return a.haplogroupFrequencies(func(anc Ancestry) string { return anc.MtHaplogroup })
+}
+
+// haplogroupFrequencies counts the haplogroups returned by accFunc.
+func (a *Ancestries) haplogroupFrequencies(accFunc func(Ancestry) string) Frequencies {
+	counts := make(map[string]int)
+	for _, ancestry := range *a {
+		if haplogroup := accFunc(ancestry); haplogroup != "" {
+			counts[haplogroup]++
+		}
+	}
+	result := make(Frequencies, 0, len(counts))
+	for haplogroup, count := range counts {
+		result = append(result, Frequency{NCousins: count, Name: haplogroup})
+	}
+	return result
+}
+
+// LocationsByYHaplogroup returns for each Y-DNA haplogroup the
+// Frequencies of the ancestral locations of its cousins.
+func (a *Ancestries) LocationsByYHaplogroup() map[string]Frequencies {
+	return a.locationsByHaplogroup(func(anc Ancestry) string { return anc.YHaplogroup })
+}
+
+// LocationsByMtHaplogroup returns for each mtDNA haplogroup the
+// Frequencies of the ancestral locations of its cousins.
+func (a *Ancestries) LocationsByMtHaplogroup() map[string]Frequencies {
+	return a.locationsByHaplogroup(func(anc Ancestry) string { return anc.MtHaplogroup })
+}
+
+// locationsByHaplogroup groups the cousins by the haplogroups
+// returned by accFunc and counts their locations.
+func (a *Ancestries) locationsByHaplogroup(accFunc func(Ancestry) string) map[string]Frequencies {
+	groups := make(map[string]Ancestries)
+	for _, ancestry := range *a {
+		if haplogroup := accFunc(ancestry); haplogroup != "" {
+			groups[haplogroup] = append(groups[haplogroup], ancestry)
+		}
+	}
+	result := make(map[string]Frequencies, len(groups))
+	for haplogroup, group := range groups {
+		result[haplogroup] = group.FrequenciesOfLocations(group.Locations())
+	}
+	return result
+}
@@ -0,0 +1,31 @@
+package cousins
+
+import "testing"
+
+func TestMatchHaplogroup(t *testing.T) {
+	tests := []struct {
+		haplogroup, patterns string
+		want                 bool
+	}{
+		{"H", "H*", true},
+		{"H1", "H*", true},
+		{"H1a1", "h*", true},
+		{"HV", "H*", false},
+		{"HV0", "H*", false},
+		{"HV0", "HV*", true},
+		{"H10", "H1*", false},
+		{"H1a", "H1*", true},
+		{"R-U106", "R*", true},
+		{"R-U1060", "R-U106*", false},
+		{"R1b-U106", "R1b*", true},
+		{"I-M253", "R*, I*", true},
+		{"H1", "H", false},
+		{"H", "H", true},
+		{"", "*", false},
+	}
+	for _, test := range tests {
+		if got := MatchHaplogroup(test.haplogroup, test.patterns); got != test.want {
+			t.Errorf("MatchHaplogroup(%q, %q) = %v, want %v", test.haplogroup, test.patterns, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package cousins
+
+// Index is an inverted index for Ancestries. It maps each word,
+// token and entry search term to a posting list, which contains the
+// positions of all Ancestries that contain it in ascending order.
+// Filters on an Index are set operations on posting lists
+// and do not need to scan all Ancestries. Subsets of the indexed
+// Ancestries are given by their positions, so that an Index can be
+// built once and used for all filters on the same Ancestries.
+type Index struct {
+	ancestries Ancestries
+	postings   map[string][]int
+}
+
+// NewIndex creates an Index for the Ancestries a.
+func NewIndex(a Ancestries) *Index {
+	postings := make(map[string][]int)
+	for i, ancestry := range a {
+		for word, _ := range ancestry.Words {
+			postings[word] = append(postings[word], i)
+		}
+		for token, _ := range ancestry.Tokens {
+			// Avoid double entries for tokens consisting of a single word.
+			if !ancestry.Words[token] {
+				postings[token] = append(postings[token], i)
+			}
+		}
+		for key, _ := range ancestry.entryKeys() {
+			postings[key] = append(postings[key], i)
+		}
+		for tag, _ := range ancestry.Tags {
+			postings[tagPrefix+tag] = append(postings[tagPrefix+tag], i)
+		}
+	}
+	return &Index{ancestries: a, postings: postings}
+}
+
+// Ancestries returns the indexed Ancestries.
+func (x *Index) Ancestries() Ancestries {
+	return x.ancestries
+}
+
+// Postings returns the positions of all Ancestries that contain name
+// in ascending order. See Ancestry.Contains.
+func (x *Index) Postings(name string) []int {
+	return x.postings[normalizeTerm(name)]
+}
+
+// All returns the positions of all indexed Ancestries.
+func (x *Index) All() []int {
+	result := make([]int, len(x.ancestries))
+	for i := range result {
+		result[i] = i
+	}
+	return result
+}
+
+// Select returns the Ancestries at the given positions.
+func (x *Index) Select(positions []int) Ancestries {
+	result := make(Ancestries, len(positions))
+	for i, position := range positions {
+		result[i] = x.ancestries[position]
+	}
+	return result
+}
+
+// Include returns the Ancestries that contain at least one of names.
+// The Ancestries that contain the first name come first, followed by
+// the remaining Ancestries that contain the second name and so on.
+func (x *Index) Include(names ...string) Ancestries {
+	return x.Select(x.IncludeAt(x.All(), names...))
+}
+
+// Exclude returns the Ancestries that contain none of names
+// in their original order.
+func (x *Index) Exclude(names ...string) Ancestries {
+	return x.Select(x.ExcludeAt(x.All(), names...))
+}
+
+// IncludeAt returns those of the given positions whose Ancestries
+// contain at least one of names, ordered like the result of Include.
+// Only the posting lists of names and the given positions are read.
+func (x *Index) IncludeAt(positions []int, names ...string) []int {
+	selected := x.membership(positions)
+	result := make([]int, 0)
+	included := make(map[int]bool)
+	for _, name := range names {
+		for _, i := range x.Postings(name) {
+			if selected(i) && !included[i] {
+				result = append(result, i)
+				included[i] = true
+			}
+		}
+	}
+	return result
+}
+
+// ExcludeAt returns those of the given positions whose Ancestries
+// contain none of names in their original order.
+func (x *Index) ExcludeAt(positions []int, names ...string) []int {
+	excluded := make(map[int]bool)
+	for _, name := range names {
+		for _, i := range x.Postings(name) {
+			excluded[i] = true
+		}
+	}
+	result := make([]int, 0, len(positions))
+	for _, i := range positions {
+		if !excluded[i] {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// Where returns those of the given positions whose Ancestries
+// satisfy keep in their original order.
+func (x *Index) Where(positions []int, keep func(a *Ancestry) bool) []int {
+	result := make([]int, 0, len(positions))
+	for _, i := range positions {
+		if keep(&x.ancestries[i]) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// membership returns a function that reports whether a position is
+// one of positions. Positions are distinct, so if there are as many
+// positions as Ancestries, all Ancestries are selected.
+func (x *Index) membership(positions []int) func(i int) bool {
+	if len(positions) == len(x.ancestries) {
+		return func(i int) bool { return true }
+	}
+	selected := make(map[int]bool, len(positions))
+	for _, i := range positions {
+		selected[i] = true
+	}
+	return func(i int) bool { return selected[i] }
+}
@@ -0,0 +1,93 @@
+package cousins
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testAncestries returns Ancestries with the matches A to E.
+func testAncestries() Ancestries {
+	lines := []string{
+		"Smith (Virginia)",
+		"Meier (Bavaria) / Smith (Germany)",
+		"Weber (Bavaria)",
+		"Murphy (Cork, Ireland)",
+		"Jones (Ohio)",
+	}
+	result := make(Ancestries, len(lines))
+	for i, line := range lines {
+		result[i] = NewAncestry(line)
+		result[i].Match = string(rune('A' + i))
+	}
+	return result
+}
+
+// matches returns the names of the matches of a.
+func matches(a Ancestries) []string {
+	result := make([]string, len(a))
+	for i, ancestry := range a {
+		result[i] = ancestry.Match
+	}
+	return result
+}
+
+func TestIndexInclude(t *testing.T) {
+	x := NewIndex(testAncestries())
+	// Cousins with the first term come first.
+	if got := matches(x.Include("bavaria", "smith")); !reflect.DeepEqual(got, []string{"B", "C", "A"}) {
+		t.Errorf("Include(bavaria, smith) = %v, want [B C A]", got)
+	}
+	if got := matches(x.Include("usa")); !reflect.DeepEqual(got, []string{"A", "E"}) {
+		t.Errorf("Include(usa) = %v, want [A E]", got)
+	}
+	if got := x.Include("unknown"); len(got) != 0 {
+		t.Errorf("Include(unknown) = %v, want nothing", matches(got))
+	}
+}
+
+func TestIndexExclude(t *testing.T) {
+	x := NewIndex(testAncestries())
+	if got := matches(x.Exclude("smith", "ireland")); !reflect.DeepEqual(got, []string{"C", "E"}) {
+		t.Errorf("Exclude(smith, ireland) = %v, want [C E]", got)
+	}
+}
+
+func TestIndexFiltersAt(t *testing.T) {
+	x := NewIndex(testAncestries())
+	// Filters on a subset only return positions of the subset.
+	positions := x.ExcludeAt(x.All(), "ohio")
+	positions = x.IncludeAt(positions, "usa", "bavaria")
+	if !reflect.DeepEqual(positions, []int{0, 1, 2}) {
+		t.Errorf("IncludeAt = %v, want [0 1 2]", positions)
+	}
+	positions = x.IncludeAt([]int{4, 3}, "usa", "ireland")
+	if !reflect.DeepEqual(positions, []int{4, 3}) {
+		t.Errorf("IncludeAt([4 3], usa, ireland) = %v, want [4 3]", positions)
+	}
+	positions = x.Where([]int{2, 1, 0}, func(a *Ancestry) bool { return a.Names["smith"] })
+	if got := matches(x.Select(positions)); !reflect.DeepEqual(got, []string{"B", "A"}) {
+		t.Errorf("Where = %v, want [B A]", got)
+	}
+	if positions := x.IncludeAt([]int{}, "smith"); len(positions) != 0 {
+		t.Errorf("IncludeAt on empty positions = %v", positions)
+	}
+}
+
+func TestIndexAgreesWithContains(t *testing.T) {
+	ancestries := testAncestries()
+	for _, term := range []string{"smith", "bavaria", "germany", "usa", "cork", "surname:smith", "location:virginia", "nothing"} {
+		var want []string
+		for _, ancestry := range ancestries {
+			if ancestry.Contains(term) {
+				want = append(want, ancestry.Match)
+			}
+		}
+		got := matches(ancestries.Include(term))
+		if len(got) != len(want) || len(got) > 0 && !reflect.DeepEqual(got, want) {
+			t.Errorf("Include(%q) = %v, want %v", term, got, want)
+		}
+		if n := len(ancestries.Exclude(term)); n != len(ancestries)-len(want) {
+			t.Errorf("Exclude(%q) returned %d cousins, want %d", term, n, len(ancestries)-len(want))
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package cousins
+
+import (
+	"runtime"
+	"sync"
+)
+
+// minParallel is the minimal number of work items for parallel
+// processing. Fewer items are processed sequentially, because
+// starting goroutines would take longer than the work itself.
+const minParallel = 256
+
+// parallelFor calls f(i) for all i from 0 to n-1 using a pool of
+// one worker per CPU. Each worker processes a contiguous range of
+// items. f must only write to data that belongs to item i, so that
+// the results do not depend on the order of execution.
+func parallelFor(n int, f func(i int)) {
+	workers := runtime.NumCPU()
+	if n < minParallel || workers < 2 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+	if workers > n {
+		workers = n
+	}
+	var wg sync.WaitGroup
+	chunk := (n + workers - 1) / workers
+	for start := 0; start < n; start += chunk {
+		end := start + chunk
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				f(i)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// parallelLoad calls load(i) for all i from 0 to n-1 with at most
+// one concurrent call per CPU. The first error in order of i
+// is returned.
+func parallelLoad(n int, load func(i int) error) error {
+	errs := make([]error, n)
+	sem := make(chan bool, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- true
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = load(i)
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package cousins
+
+import (
+	"regexp"
+	"strings"
+)
+
+// countyPattern finds the forms "Co. Cork" and "Co Cork" of
+// county names.
+var countyPattern = regexp.MustCompile(`(^|[\s,;/&(-])co\.?\s+(\pL+)`)
+
+// expandCounties replaces the abbreviation "co." for county
+// by "county" if it is followed by a known county name, like in
+// "Co. Cork". Otherwise "co" stays the abbreviation for Colorado,
+// like in "Denver, CO USA".
+func expandCounties(text string) string {
+	return countyPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := countyPattern.FindStringSubmatch(match)
+		name := parts[2]
+		_, known := regionTags[name]
+		if _, ok := regionTags["county "+name]; ok {
+			known = true
+		}
+		if !known {
+			return match
+		}
+		return parts[1] + "county " + name
+	})
+}
+
+// contextTags contains regions whose names or abbreviations have other
+// meanings, like NL for the Netherlands, PE for Peru, SK for Slovakia,
+// ON and SA for South Africa, or are common place names, like Victoria.
+// They are only normalized if the country, which is the last clean tag,
+// is given as well, like in "Regina, SK, Canada".
+var contextTags = map[string][]string{
+	"nl":       {"newfoundland", "canada"},
+	"on":       {"ontario", "canada"},
+	"pe":       {"prince edward island", "canada"},
+	"sk":       {"saskatchewan", "canada"},
+	"sa":       {"south australia", "australia"},
+	"vic":      {"victoria", "australia"},
+	"victoria": {"victoria", "australia"},
+}
+
+// addContextTags adds the normalized forms of the tokens in contextTags
+// to the normalized tokens in result, if their country is contained
+// in result. The original tokens are removed from result.
+func addContextTags(tokens, result map[string]bool) {
+	for token, _ := range tokens {
+		clean, ok := contextTags[token]
+		if !ok || !result[clean[len(clean)-1]] {
+			continue
+		}
+		delete(result, token)
+		for _, tag := range clean {
+			result[tag] = true
+		}
+	}
+}
+
+// cleanTags returns the normalized form of a token
+// from dirtyTags or regionTags.
+func cleanTags(token string) ([]string, bool) {
+	if clean, ok := dirtyTags[token]; ok {
+		return clean, true
+	}
+	if strings.HasPrefix(token, "county ") {
+		// "County Cork" is the same as "Cork".
+		if clean, ok := regionTags[strings.TrimPrefix(token, "county ")]; ok {
+			return clean, true
+		}
+	}
+	clean, ok := regionTags[token]
+	return clean, ok
+}
+
+// regionParents maps regions in small caps to the larger
+// regions that contain them.
+var regionParents = map[string]string{
+	"alaska":           "usa",
+	"england":          "united kingdom",
+	"hawaii":           "usa",
+	"northern ireland": "united kingdom",
+	"scotland":         "united kingdom",
+	"tbilisi":          "georgia",
+	"wales":            "united kingdom",
+}
+
+// isPartOf reports whether the region specific is part of the region
+// general, according to regionParents, or general is only a word of the
+// name of specific, like "guinea" of "papua new guinea". Both names
+// must be in small caps.
+func isPartOf(specific, general string) bool {
+	for parent, ok := regionParents[specific]; ok; parent, ok = regionParents[parent] {
+		if parent == general {
+			return true
+		}
+	}
+	if specific == general || !strings.Contains(specific, " ") {
+		return false
+	}
+	for _, word := range strings.Fields(specific) {
+		if word == general {
+			return true
+		}
+	}
+	return false
+}
+
+// regionTags maps provinces, counties and states outside the USA to
+// their normalized names and countries, like dirtyTags does for US
+// states. Names and abbreviations that are also common in the USA,
+// are part of US state names or have other meanings are left out,
+// for example Kent, Essex, Norfolk, Hampshire, Berlin and WA
+// (Washington). Some of them are only recognized in forms like
+// "County Durham" or "Co. Down", others are in contextTags.
+// Hamburg and Bremen are included, although there are small places
+// with these names in the USA. Parts of names with hyphens are listed
+// separately, because hyphens separate tokens.
+var regionTags = map[string][]string{
+	"shire": {}, // part of "inverness-shire" or "ross-shire"
+
+	// Canadian provinces and territories.
+	"ab":                    {"alberta", "canada"},
+	"alberta":               {"alberta", "canada"},
+	"bc":                    {"british columbia", "canada"},
+	"british columbia":      {"british columbia", "canada"},
+	"manitoba":              {"manitoba", "canada"},
+	"mb":                    {"manitoba", "canada"},
+	"nb":                    {"new brunswick", "canada"},
+	"new brunswick":         {"new brunswick", "canada"},
+	"newfoundland":          {"newfoundland", "canada"},
+	"northwest territories": {"northwest territories", "canada"},
+	"nova scotia":           {"nova scotia", "canada"},
+	"ns":                    {"nova scotia", "canada"},
+	"nu":                    {"nunavut", "canada"},
+	"nunavut":               {"nunavut", "canada"},
+	"nwt":                   {"northwest territories", "canada"},
+	"ontario":               {"ontario", "canada"},
+	"pei":                   {"prince edward island", "canada"},
+	"prince edward island":  {"prince edward island", "canada"},
+	"qc":                    {"quebec", "canada"},
+	"quebec":                {"quebec", "canada"},
+	"québec":                {"quebec", "canada"},
+	"saskatchewan":          {"saskatchewan", "canada"},
+	"upper canada":          {"ontario", "canada"},
+	"lower canada":          {"quebec", "canada"},
+	"yukon":                 {"yukon", "canada"},
+	"yt":                    {"yukon", "canada"},
+
+	// English counties.
+	"bedfordshire":     {"bedfordshire", "england"},
+	"berkshire":        {"berkshire", "england"},
+	"buckinghamshire":  {"buckinghamshire", "england"},
+	"cambridgeshire":   {"cambridgeshire", "england"},
+	"cheshire":         {"cheshire", "england"},
+	"cornwall":         {"cornwall", "england"},
+	"cumberland":       {"cumberland", "england"},
+	"cumbria":          {"cumbria", "england"},
+	"county durham":    {"durham", "england"},
+	"county middlesex": {"middlesex", "england"},
+	"county suffolk":   {"suffolk", "england"},
+	"county surrey":    {"surrey", "england"},
+	"county sussex":    {"sussex", "england"},
+	"derbyshire":       {"derbyshire", "england"},
+	"devon":            {"devon", "england"},
+	"dorset":           {"dorset", "england"},
+	"gloucestershire":  {"gloucestershire", "england"},
+	"herefordshire":    {"herefordshire", "england"},
+	"hertfordshire":    {"hertfordshire", "england"},
+	"huntingdonshire":  {"huntingdonshire", "england"},
+	"lancashire":       {"lancashire", "england"},
+	"leicestershire":   {"leicestershire", "england"},
+	"lincolnshire":     {"lincolnshire", "england"},
+	"northamptonshire": {"northamptonshire", "england"},
+	"northumberland":   {"northumberland", "england"},
+	"nottinghamshire":  {"nottinghamshire", "england"},
+	"oxfordshire":      {"oxfordshire", "england"},
+	"shropshire":       {"shropshire", "england"},
+	"somerset":         {"somerset", "england"},
+	"staffordshire":    {"staffordshire", "england"},
+	"warwickshire":     {"warwickshire", "england"},
+	"westmorland":      {"westmorland", "england"},
+	"wiltshire":        {"wiltshire", "england"},
+	"worcestershire":   {"worcestershire", "england"},
+	"yorkshire":        {"yorkshire", "england"},
+
+	// Welsh counties.
+	"anglesey":        {"anglesey", "wales"},
+	"brecknockshire":  {"brecknockshire", "wales"},
+	"caernarfonshire": {"caernarfonshire", "wales"},
+	"cardiganshire":   {"cardiganshire", "wales"},
+	"carmarthenshire": {"carmarthenshire", "wales"},
+	"denbighshire":    {"denbighshire", "wales"},
+	"flintshire":      {"flintshire", "wales"},
+	"glamorgan":       {"glamorgan", "wales"},
+	"merionethshire":  {"merionethshire", "wales"},
+	"monmouthshire":   {"monmouthshire", "wales"},
+	"montgomeryshire": {"montgomeryshire", "wales"},
+	"pembrokeshire":   {"pembrokeshire", "wales"},
+	"radnorshire":     {"radnorshire", "wales"},
+
+	// Scottish counties.
+	"aberdeenshire":      {"aberdeenshire", "scotland"},
+	"argyll":             {"argyll", "scotland"},
+	"ayrshire":           {"ayrshire", "scotland"},
+	"banffshire":         {"banffshire", "scotland"},
+	"berwickshire":       {"berwickshire", "scotland"},
+	"caithness":          {"caithness", "scotland"},
+	"dumfriesshire":      {"dumfriesshire", "scotland"},
+	"fife":               {"fife", "scotland"},
+	"kincardineshire":    {"kincardineshire", "scotland"},
+	"kirkcudbrightshire": {"kirkcudbrightshire", "scotland"},
+	"lanarkshire":        {"lanarkshire", "scotland"},
+	"midlothian":         {"midlothian", "scotland"},
+	"perthshire":         {"perthshire", "scotland"},
+	"renfrewshire":       {"renfrewshire", "scotland"},
+	"roxburghshire":      {"roxburghshire", "scotland"},
+	"stirlingshire":      {"stirlingshire", "scotland"},
+	"wigtownshire":       {"wigtownshire", "scotland"},
+
+	// Irish counties, including Northern Ireland. They are
+	// counted as Ireland, which is how genealogists use it
+	// for the time before 1921.
+	"antrim":          {"antrim", "ireland"},
+	"armagh":          {"armagh", "ireland"},
+	"carlow":          {"carlow", "ireland"},
+	"cavan":           {"cavan", "ireland"},
+	"county clare":    {"clare", "ireland"},
+	"cork":            {"cork", "ireland"},
+	"county dublin":   {"dublin", "ireland"},
+	"donegal":         {"donegal", "ireland"},
+	"county down":     {"down", "ireland"},
+	"fermanagh":       {"fermanagh", "ireland"},
+	"galway":          {"galway", "ireland"},
+	"county kerry":    {"kerry", "ireland"},
+	"kildare":         {"kildare", "ireland"},
+	"kilkenny":        {"kilkenny", "ireland"},
+	"laois":           {"laois", "ireland"},
+	"leitrim":         {"leitrim", "ireland"},
+	"limerick":        {"limerick", "ireland"},
+	"londonderry":     {"londonderry", "ireland"},
+	"county longford": {"longford", "ireland"},
+	"louth":           {"louth", "ireland"},
+	"mayo":            {"mayo", "ireland"},
+	"meath":           {"meath", "ireland"},
+	"monaghan":        {"monaghan", "ireland"},
+	"offaly":          {"offaly", "ireland"},
+	"roscommon":       {"roscommon", "ireland"},
+	"sligo":           {"sligo", "ireland"},
+	"tipperary":       {"tipperary", "ireland"},
+	"county tyrone":   {"tyrone", "ireland"},
+	"waterford":       {"waterford", "ireland"},
+	"westmeath":       {"westmeath", "ireland"},
+	"wexford":         {"wexford", "ireland"},
+	"wicklow":         {"wicklow", "ireland"},
+
+	// German Bundesländer and historical Länder.
+	"anhalt":        {"anhalt", "germany"},
+	"baden":         {"baden", "germany"},
+	"bavaria":       {"bavaria", "germany"},
+	"bayern":        {"bavaria", "germany"},
+	"brandenburg":   {"brandenburg", "germany"},
+	"braunschweig":  {"brunswick", "germany"},
+	"bremen":        {"bremen", "germany"},
+	"east prussia":  {"east prussia", "germany"},
+	"hamburg":       {"hamburg", "germany"},
+	"hannover":      {"hanover", "germany"},
+	"hesse":         {"hesse", "germany"},
+	"hessen":        {"hesse", "germany"},
+	"holstein":      {"holstein", "germany"},
+	"lower saxony":  {"lower saxony", "germany"},
+	"mecklenburg":   {"mecklenburg", "germany"},
+	"niedersachsen": {"lower saxony", "germany"},
+	"oldenburg":     {"oldenburg", "germany"},
+	"ostpreussen":   {"east prussia", "germany"},
+	"ostpreußen":    {"east prussia", "germany"},
+	"palatinate":    {"palatinate", "germany"},
+	"pfalz":         {"palatinate", "germany"},
+	"pomerania":     {"pomerania", "germany"},
+	"pommern":       {"pomerania", "germany"},
+	"preussen":      {"prussia", "germany"},
+	"preußen":       {"prussia", "germany"},
+	"prussia":       {"prussia", "germany"},
+	"rheinland":     {"rhineland", "germany"},
+	"rhineland":     {"rhineland", "germany"},
+	"saarland":      {"saarland", "germany"},
+	"sachsen":       {"saxony", "germany"},
+	"saxony":        {"saxony", "germany"},
+	"schleswig":     {"schleswig", "germany"},
+	"thuringia":     {"thuringia", "germany"},
+	"thüringen":     {"thuringia", "germany"},
+	"vorpommern":    {"western pomerania", "germany"},
+	"west prussia":  {"west prussia", "germany"},
+	"westfalen":     {"westphalia", "germany"},
+	"westphalia":    {"westphalia", "germany"},
+	"westpreussen":  {"west prussia", "germany"},
+	"wuerttemberg":  {"württemberg", "germany"},
+	"württemberg":   {"württemberg", "germany"},
+
+	// Australian states and territories.
+	"australian capital territory": {"australian capital territory", "australia"},
+	"new south wales":              {"new south wales", "australia"},
+	"northern territory":           {"northern territory", "australia"},
+	"nsw":                          {"new south wales", "australia"},
+	"qld":                          {"queensland", "australia"},
+	"queensland":                   {"queensland", "australia"},
+	"south australia":              {"south australia", "australia"},
+	"tas":                          {"tasmania", "australia"},
+	"tasmania":                     {"tasmania", "australia"},
+	"western australia":            {"western australia", "australia"},
+}
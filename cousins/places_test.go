@@ -0,0 +1,93 @@
+package cousins
+
+import "testing"
+
+func TestCountyAbbreviation(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"Smith (Denver, CO USA)", []string{"colorado", "denver", "usa"}},
+		{"Smith (Boulder Co USA)", []string{"colorado", "boulder", "usa"}},
+		{"Murphy (Co. Cork)", []string{"cork", "ireland"}},
+		{"Murphy (Co Kerry, Ireland)", []string{"kerry", "ireland"}},
+	}
+	for _, test := range tests {
+		a := NewAncestry(test.line)
+		for _, word := range test.want {
+			if !a.Words[word] {
+				t.Errorf("NewAncestry(%q).Words = %v, missing %q", test.line, a.Words, word)
+			}
+		}
+	}
+}
+
+func TestRegionTags(t *testing.T) {
+	tests := []struct {
+		place string
+		want  string
+	}{
+		{"bc", "british columbia"},
+		{"newfoundland", "newfoundland"},
+		{"devon", "devon"},
+		{"county surrey", "surrey"},
+		{"co. sussex", "sussex"},
+		{"hamburg", "hamburg"},
+		{"ostpreussen", "east prussia"},
+		{"pommern", "pomerania"},
+	}
+	for _, test := range tests {
+		if got := normalizePlace(test.place); got != test.want {
+			t.Errorf("normalizePlace(%q) = %q, want %q", test.place, got, test.want)
+		}
+	}
+	a := NewAncestry("Lee (BC, Canada)")
+	if !a.Locations["british columbia"] || !a.Locations["canada"] {
+		t.Errorf("NewAncestry(\"Lee (BC, Canada)\").Locations = %v", a.Locations)
+	}
+	a = NewAncestry("Krause (Vorpommern)")
+	if !a.Locations["germany"] {
+		t.Errorf("NewAncestry(\"Krause (Vorpommern)\").Locations = %v, missing germany", a.Locations)
+	}
+}
+
+func TestContextTags(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		notWant []string
+	}{
+		{"Jansen (NL)", nil, []string{"newfoundland", "canada"}},
+		{"Novak (SK)", nil, []string{"saskatchewan", "canada"}},
+		{"Garcia (Lima, PE)", nil, []string{"prince edward island", "canada"}},
+		{"Lee (St. John's, NL, Canada)", []string{"newfoundland", "canada"}, nil},
+		{"Smith (Toronto, ON, Canada)", []string{"ontario", "canada"}, nil},
+		{"Brown (Melbourne, Victoria, Australia)", []string{"victoria", "australia"}, nil},
+		{"Brown (Melbourne, VIC, Australia)", []string{"victoria", "australia"}, nil},
+		{"Hill (Adelaide, SA, Australia)", []string{"south australia", "australia"}, nil},
+		{"Smith (Victoria, BC, Canada)", []string{"british columbia", "canada"}, []string{"australia"}},
+	}
+	for _, test := range tests {
+		a := NewAncestry(test.line)
+		for _, word := range test.want {
+			if !a.Locations[word] {
+				t.Errorf("NewAncestry(%q).Locations = %v, missing %q", test.line, a.Locations, word)
+			}
+		}
+		for _, word := range test.notWant {
+			if a.Locations[word] {
+				t.Errorf("NewAncestry(%q).Locations = %v, contains %q", test.line, a.Locations, word)
+			}
+		}
+	}
+}
+
+func TestUSCountyNames(t *testing.T) {
+	a := NewAncestry("Miller (Orange County, California)")
+	if !a.Locations["orange county"] {
+		t.Errorf("NewAncestry(\"Miller (Orange County, California)\").Locations = %v, missing \"orange county\"", a.Locations)
+	}
+	if a.Locations["orange"] {
+		t.Errorf("NewAncestry(\"Miller (Orange County, California)\").Locations = %v, contains \"orange\"", a.Locations)
+	}
+}
@@ -0,0 +1,79 @@
+package cousins
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Population contains the number of DNA testers or inhabitants
+// per country or region. Names are normalized like ancestral
+// locations, so that "United States" and "USA" are the same.
+type Population map[string]float64
+
+// ReadPopulation reads the number of testers per country or region
+// from a file in CSV format. The columns are found by captions like
+// "Country" and "Testers", otherwise the first two columns are used.
+// encoding is the character encoding of the file.
+func ReadPopulation(filename string, encoding string) (Population, error) {
+	records, err := readCSV(filename, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty file")
+	}
+	cols := newColumns(records[0])
+	nameCol := cols.index("country", "region", "location", "name")
+	countCol := cols.index("testers", "population", "count", "number")
+	rows := records[1:]
+	if nameCol < 0 || countCol < 0 {
+		// No captions, so the first row contains data.
+		nameCol, countCol, rows = 0, 1, records
+	}
+	result := make(Population)
+	for i, row := range rows {
+		name := normalizePlace(strings.ToLower(field(row, nameCol)))
+		value := strings.Replace(field(row, countCol), ",", "", -1)
+		count, err := strconv.ParseFloat(value, 64)
+		if name == "" || err != nil || count <= 0 {
+			return nil, fmt.Errorf("line %d of %s: a name and a positive number are required", i+1, filename)
+		}
+		result[name] = count
+	}
+	return result, nil
+}
+
+// Count returns the number of testers of a region
+// and whether the region is known.
+func (p Population) Count(region string) (float64, bool) {
+	count, ok := p[normalizePlace(strings.ToLower(region))]
+	return count, ok
+}
+
+// Corrections returns correction factors for the given regions, which
+// are inversely proportional to the number of testers. Regions that are
+// not known are assumed to have the median number of testers.
+// The keys of the result are in small caps.
+func (p Population) Corrections(regions map[string]bool) map[string]float64 {
+	counts := make([]float64, 0, len(p))
+	for _, count := range p {
+		counts = append(counts, count)
+	}
+	sort.Float64s(counts)
+	median := 1.0
+	if len(counts) > 0 {
+		median = (counts[(len(counts)-1)/2] + counts[len(counts)/2]) / 2
+	}
+	result := make(map[string]float64, len(regions))
+	for region, _ := range regions {
+		count, ok := p.Count(region)
+		if !ok {
+			count = median
+		}
+		result[strings.ToLower(region)] = median / count
+	}
+	return result
+}
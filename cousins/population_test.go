@@ -0,0 +1,24 @@
+package cousins
+
+import "testing"
+
+func TestPopulationNormalizesNames(t *testing.T) {
+	filename := writeTempFile(t, "testers.csv", "Country,Testers\r\nUnited States,\"20,000,000\"\r\nDeutschland,400000\r\n")
+	population, err := ReadPopulation(filename, "utf-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		region string
+		want   float64
+	}{
+		{"USA", 20000000},
+		{"United States", 20000000},
+		{"germany", 400000},
+	}
+	for _, test := range tests {
+		if got, ok := population.Count(test.region); !ok || got != test.want {
+			t.Errorf("Count(%q) = %v, %v, want %v", test.region, got, ok, test.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package cousins
+
+import (
+	"math"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parentCM is the DNA shared with a parent in centimorgans. The common
+// ancestors of nth cousins share about parentCM / 4^n centimorgans with
+// them and make up 1/2^n of their ancestry.
+const parentCM = 3464
+
+// defaultCM is assumed for matches without known shared DNA or
+// relationship. This is typical for distant cousins.
+const defaultCM = 20
+
+// cousinDegreePattern finds degrees of cousinship like "3rd cousin".
+var cousinDegreePattern = regexp.MustCompile(`(\d+)(?:st|nd|rd|th)\s+cousin`)
+
+// EstimatedCM returns the SharedCM of the cousin or, if unknown,
+// the length expected for the Relationship. For relationship ranges
+// like "2nd Cousin - 4th Cousin" the middle degree is used.
+func (a *Ancestry) EstimatedCM() float64 {
+	if a.SharedCM > 0 {
+		return a.SharedCM
+	}
+	var sum, n float64
+	for _, match := range cousinDegreePattern.FindAllStringSubmatch(strings.ToLower(a.Relationship), -1) {
+		if degree, err := strconv.Atoi(match[1]); err == nil && degree > 0 {
+			sum += float64(degree)
+			n++
+		}
+	}
+	if n == 0 {
+		return defaultCM
+	}
+	return parentCM / math.Pow(4, sum/n)
+}
+
+// AncestralShare estimates which share of one's ancestry is represented
+// by the common ancestors with the cousin, 1/2 for first cousins, 1/4
+// for second cousins and so on. It is derived from EstimatedCM.
+func (a *Ancestry) AncestralShare() float64 {
+	return math.Min(1, math.Sqrt(a.EstimatedCM()/parentCM))
+}
+
+// Proportion is the estimated share of a region in one's ancestry.
+type Proportion struct {
+	// Name is the name of the region.
+	Name string
+	// Share is the estimated proportion between 0 and 1.
+	Share float64
+	// Low and High are the bounds of the 95% confidence interval.
+	Low, High float64
+}
+
+// Proportions estimates the ancestral proportions of the given regions,
+// usually countries. Each cousin's AncestralShare is divided equally
+// among the regions found in the cousin's ancestral information, where
+// nested regions count only for the most specific one, and the
+// evidence of all cousins is normalized to a total of 1. Cousins without
+// any of the regions are ignored. If correction is not nil, the evidence
+// for each region is multiplied with its factor in correction, for example
+// to correct for the number of testers per country. The confidence
+// intervals are calculated from n resamples of the cousins and r is the
+// source of random numbers. The result is ordered by share.
+func (a *Ancestries) Proportions(regions map[string]bool, correction map[string]float64, n int, r *rand.Rand) []Proportion {
+	// Regions are compared in small caps, but returned as given.
+	var names []string
+	index := make(map[string]int, len(regions))
+	for region, _ := range regions {
+		lower := strings.ToLower(region)
+		if _, exists := index[lower]; !exists {
+			index[lower] = len(names)
+			names = append(names, region)
+		}
+	}
+
+	// evidence[c] contains the weighted evidence of cousin c per region.
+	type evidence struct {
+		region int
+		weight float64
+	}
+	var cousins [][]evidence
+	for _, ancestry := range *a {
+		found := make(map[int]bool)
+		for word, _ := range ancestry.Words {
+			if i, ok := index[word]; ok {
+				found[i] = true
+			}
+		}
+		for token, _ := range ancestry.Tokens {
+			if i, ok := index[token]; ok {
+				found[i] = true
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+		// Nested regions like England and United Kingdom are
+		// evidence for the most specific region only.
+		for i, _ := range found {
+			for j, _ := range found {
+				if i != j && isPartOf(strings.ToLower(names[i]), strings.ToLower(names[j])) {
+					delete(found, j)
+				}
+			}
+		}
+		share := ancestry.AncestralShare() / float64(len(found))
+		var e []evidence
+		for i, _ := range found {
+			weight := share
+			if correction != nil {
+				weight *= correction[strings.ToLower(names[i])]
+			}
+			e = append(e, evidence{region: i, weight: weight})
+		}
+		cousins = append(cousins, e)
+	}
+
+	// estimate calculates the proportions for the given number of
+	// draws of each cousin.
+	estimate := func(draws []int) []float64 {
+		result := make([]float64, len(names))
+		var total float64
+		for c, e := range cousins {
+			if draws != nil && draws[c] == 0 {
+				continue
+			}
+			count := 1.0
+			if draws != nil {
+				count = float64(draws[c])
+			}
+			for _, ev := range e {
+				result[ev.region] += count * ev.weight
+				total += count * ev.weight
+			}
+		}
+		if total > 0 {
+			for i := range result {
+				result[i] /= total
+			}
+		}
+		return result
+	}
+
+	shares := estimate(nil)
+	samples := make([][]float64, len(names))
+	if len(cousins) > 0 {
+		draws := make([]int, len(cousins))
+		for s := 0; s < n; s++ {
+			for c := range draws {
+				draws[c] = 0
+			}
+			for k := 0; k < len(draws); k++ {
+				draws[r.Intn(len(draws))]++
+			}
+			for i, share := range estimate(draws) {
+				samples[i] = append(samples[i], share)
+			}
+		}
+	}
+
+	var result []Proportion
+	for i, name := range names {
+		if shares[i] == 0 {
+			continue
+		}
+		p := Proportion{Name: name, Share: shares[i], Low: shares[i], High: shares[i]}
+		if len(samples[i]) > 0 {
+			sort.Float64s(samples[i])
+			last := float64(len(samples[i]) - 1)
+			p.Low = samples[i][int(0.025*last+0.5)]
+			p.High = samples[i][int(0.975*last+0.5)]
+		}
+		result = append(result, p)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Share != result[j].Share {
+			return result[i].Share > result[j].Share
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
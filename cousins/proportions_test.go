@@ -0,0 +1,45 @@
+package cousins
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestProportionsNestedRegions(t *testing.T) {
+	a := NewAncestry("Brown (Yorkshire, England, United Kingdom)")
+	a.SharedCM = 50
+	b := NewAncestry("Weber (Germany)")
+	b.SharedCM = 50
+	ancestries := Ancestries{a, b}
+	regions := map[string]bool{"England": true, "United Kingdom": true, "Germany": true}
+
+	shares := make(map[string]float64)
+	for _, p := range ancestries.Proportions(regions, nil, 0, rand.New(rand.NewSource(1))) {
+		shares[p.Name] = p.Share
+	}
+	if math.Abs(shares["England"]-0.5) > 1e-9 || math.Abs(shares["Germany"]-0.5) > 1e-9 {
+		t.Errorf("shares = %v, want England and Germany 0.5", shares)
+	}
+	if _, ok := shares["United Kingdom"]; ok {
+		t.Errorf("United Kingdom must not be counted besides England: %v", shares)
+	}
+}
+
+func TestIsPartOf(t *testing.T) {
+	tests := []struct {
+		specific, general string
+		want              bool
+	}{
+		{"england", "united kingdom", true},
+		{"united kingdom", "england", false},
+		{"papua new guinea", "guinea", true},
+		{"guinea", "papua new guinea", false},
+		{"germany", "germany", false},
+	}
+	for _, test := range tests {
+		if got := isPartOf(test.specific, test.general); got != test.want {
+			t.Errorf("isPartOf(%q, %q) = %v, want %v", test.specific, test.general, got, test.want)
+		}
+	}
+}
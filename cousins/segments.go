@@ -0,0 +1,198 @@
+package cousins
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Segment is a DNA segment shared with a match, as exported by
+// Family Finder's chromosome browser.
+type Segment struct {
+	// Match is the name of the match.
+	Match string
+	// Chromosome is the chromosome number or "X".
+	Chromosome string
+	// Start and End are the positions of the segment on the chromosome.
+	Start, End int
+	// CM is the length of the segment in centimorgans.
+	CM float64
+}
+
+// ReadSegments reads the shared segments from a chromosome browser
+// file in CSV format. Segments shorter than minCM centimorgans are
+// ignored. If the file has no column for centimorgans, all segments
+// are read. encoding is the character encoding of the file.
+func ReadSegments(filename string, encoding string, minCM float64) ([]Segment, error) {
+	records, err := readCSV(filename, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty file")
+	}
+	cols := newColumns(records[0])
+	matchCol := cols.index("match name", "matchname", "name", "full name")
+	chrCol := cols.index("chromosome", "chr")
+	startCol := cols.index("start location", "start", "start position", "b37 start")
+	endCol := cols.index("end location", "end", "end position", "b37 end")
+	cmCol := cols.index("centimorgans", "cm", "segment cm")
+	if matchCol < 0 || chrCol < 0 || startCol < 0 || endCol < 0 {
+		return nil, fmt.Errorf("%s is not a chromosome browser file, columns for match name, chromosome, start and end needed", filename)
+	}
+
+	var result []Segment
+	for i, row := range records[1:] {
+		segment := Segment{
+			Match:      field(row, matchCol),
+			Chromosome: strings.ToUpper(field(row, chrCol)),
+		}
+		segment.Start, err = strconv.Atoi(field(row, startCol))
+		if err == nil {
+			segment.End, err = strconv.Atoi(field(row, endCol))
+		}
+		if err == nil && cmCol >= 0 {
+			segment.CM, err = strconv.ParseFloat(field(row, cmCol), 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s: %v", i+2, filename, err)
+		}
+		if segment.Match == "" || cmCol >= 0 && segment.CM < minCM {
+			continue
+		}
+		result = append(result, segment)
+	}
+	return result, nil
+}
+
+// Overlaps reports whether two segments overlap.
+func (s *Segment) Overlaps(o *Segment) bool {
+	return s.Chromosome == o.Chromosome && s.Start <= o.End && o.Start <= s.End
+}
+
+// SegmentCluster is a region of a chromosome that is covered by
+// the segments of several matches. Matches who share overlapping
+// segments are potential triangulation groups.
+type SegmentCluster struct {
+	Chromosome string
+	// Start and End are the region shared by all segments of the cluster.
+	Start, End int
+	// Segments are the segments of the cluster ordered by start position.
+	Segments []Segment
+}
+
+// Matches returns the names of all matches of the cluster in
+// the order of their segments. Each name is contained only once.
+func (c *SegmentCluster) Matches() []string {
+	var result []string
+	found := make(map[string]bool)
+	for _, segment := range c.Segments {
+		key := strings.ToLower(segment.Match)
+		if !found[key] {
+			found[key] = true
+			result = append(result, segment.Match)
+		}
+	}
+	return result
+}
+
+// ClusterSegments groups segments into clusters of overlapping
+// segments. All segments of a cluster share a common region and each
+// cluster is as large as possible. Chains of overlapping segments,
+// whose first and last segment do not overlap, are split into several
+// clusters, so that a segment may belong to more than one cluster.
+// The clusters are ordered by chromosome and position.
+func ClusterSegments(segments []Segment) []SegmentCluster {
+	sorted := append([]Segment{}, segments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Chromosome != b.Chromosome {
+			return chromosomeLess(a.Chromosome, b.Chromosome)
+		}
+		return a.Start < b.Start
+	})
+
+	var result []SegmentCluster
+	for first := 0; first < len(sorted); {
+		last := first
+		for last < len(sorted) && sorted[last].Chromosome == sorted[first].Chromosome {
+			last++
+		}
+		result = append(result, clusterChromosome(sorted[first:last])...)
+		first = last
+	}
+	return result
+}
+
+// clusterChromosome returns the clusters of the segments of a single
+// chromosome, which are ordered by start position. It sweeps over the
+// chromosome and keeps the segments that cover the current position.
+// Each time a segment ends after new segments have started, the
+// covering segments form a cluster.
+func clusterChromosome(segments []Segment) []SegmentCluster {
+	byEnd := make([]int, len(segments))
+	for i := range byEnd {
+		byEnd[i] = i
+	}
+	sort.SliceStable(byEnd, func(i, j int) bool { return segments[byEnd[i]].End < segments[byEnd[j]].End })
+
+	var result []SegmentCluster
+	// covering contains the segments that cover the current position.
+	var covering []int
+	next, grown := 0, false
+	for _, ending := range byEnd {
+		end := segments[ending].End
+		for next < len(segments) && segments[next].Start <= end {
+			covering = append(covering, next)
+			next++
+			grown = true
+		}
+		if grown {
+			cluster := SegmentCluster{Chromosome: segments[ending].Chromosome, Start: segments[covering[0]].Start, End: end}
+			for _, i := range covering {
+				cluster.Segments = append(cluster.Segments, segments[i])
+				if segments[i].Start > cluster.Start {
+					cluster.Start = segments[i].Start
+				}
+			}
+			result = append(result, cluster)
+			grown = false
+		}
+		covering = remove(covering, ending)
+	}
+	return result
+}
+
+// chromosomeLess orders chromosomes numerically, followed by
+// X and all other chromosomes in alphabetical order.
+func chromosomeLess(a, b string) bool {
+	na, erra := strconv.Atoi(a)
+	nb, errb := strconv.Atoi(b)
+	switch {
+	case erra == nil && errb == nil:
+		return na < nb
+	case erra == nil:
+		return true
+	case errb == nil:
+		return false
+	}
+	return a < b
+}
+
+// WithMatches returns the Ancestries of the given matches in the
+// order of the Ancestries. Names are compared ignoring case.
+func (a *Ancestries) WithMatches(matches []string) Ancestries {
+	names := make(map[string]bool, len(matches))
+	for _, match := range matches {
+		names[strings.ToLower(strings.TrimSpace(match))] = true
+	}
+	var result Ancestries
+	for _, ancestry := range *a {
+		if names[strings.ToLower(strings.TrimSpace(ancestry.Match))] {
+			result = append(result, ancestry)
+		}
+	}
+	return result
+}
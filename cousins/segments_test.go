@@ -0,0 +1,83 @@
+package cousins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTempFile writes content to a file in a temporary directory
+// and returns its name.
+func writeTempFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "familyties")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	filename := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestReadSegmentsMinCM(t *testing.T) {
+	filename := writeTempFile(t, "segments.csv",
+		"Match Name,Chromosome,Start Location,End Location,Centimorgans\n"+
+			"Anna,5,100,200,20.5\n"+
+			"Hans,5,150,250,3.1\n")
+	segments, err := ReadSegments(filename, "utf-8", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 1 || segments[0].Match != "Anna" {
+		t.Errorf("segments = %+v, want only Anna's segment", segments)
+	}
+}
+
+func TestReadSegmentsWithoutCM(t *testing.T) {
+	filename := writeTempFile(t, "segments.csv",
+		"Match Name,Chromosome,Start Location,End Location\n"+
+			"Anna,5,100,200\n"+
+			"Hans,5,150,250\n")
+	segments, err := ReadSegments(filename, "utf-8", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 2 {
+		t.Errorf("got %d segments, want 2", len(segments))
+	}
+}
+
+func TestClusterSegmentsSharedRegion(t *testing.T) {
+	// A overlaps B and B overlaps C, but A and C do not overlap.
+	segments := []Segment{
+		{Match: "C", Chromosome: "5", Start: 250, End: 400},
+		{Match: "A", Chromosome: "5", Start: 100, End: 200},
+		{Match: "B", Chromosome: "5", Start: 150, End: 300},
+		{Match: "D", Chromosome: "X", Start: 100, End: 200},
+		{Match: "E", Chromosome: "2", Start: 500, End: 600},
+	}
+	clusters := ClusterSegments(segments)
+	type region struct {
+		chromosome string
+		start, end int
+		matches    string
+	}
+	var got []region
+	for _, c := range clusters {
+		got = append(got, region{c.Chromosome, c.Start, c.End, strings.Join(c.Matches(), ",")})
+	}
+	want := []region{
+		{"2", 500, 600, "E"},
+		{"5", 150, 200, "A,B"},
+		{"5", 250, 300, "B,C"},
+		{"X", 100, 200, "D"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("clusters = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package cousins
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tagPrefix is the prefix of search terms for tags,
+// for example "tag:paternal".
+const tagPrefix = "tag:"
+
+// tagPattern matches tags like "#paternal" or "#hessen-line".
+var tagPattern = regexp.MustCompile(`#([\pL\pN_-]+)`)
+
+// extractTags returns the tags found in notes
+// in small caps and without "#".
+func extractTags(notes string) map[string]bool {
+	result := make(map[string]bool)
+	for _, match := range tagPattern.FindAllStringSubmatch(notes, -1) {
+		result[strings.ToLower(strings.Trim(match[1], "_-"))] = true
+	}
+	delete(result, "")
+	return result
+}
+
+// setNotes sets the Notes of the Ancestry and extracts the Tags.
+func (a *Ancestry) setNotes(notes string) {
+	a.Notes = notes
+	a.Tags = extractTags(notes)
+}
+
+// SearchNotes returns the Ancestries whose Notes contain text,
+// ignoring case.
+func (a *Ancestries) SearchNotes(text string) Ancestries {
+	result := make(Ancestries, 0, len(*a))
+	for _, ancestry := range *a {
+		if ancestry.NotesContain(text) {
+			result = append(result, ancestry)
+		}
+	}
+	return result
+}
+
+// NotesContain reports whether the notes of the cousin
+// contain text, ignoring case.
+func (a *Ancestry) NotesContain(text string) bool {
+	return strings.Contains(strings.ToLower(a.Notes), strings.ToLower(text))
+}
+
+// TagFrequencies determines how many cousins have which tag.
+func (a *Ancestries) TagFrequencies() Frequencies {
+	counts := make(map[string]int)
+	for _, ancestry := range *a {
+		for tag, _ := range ancestry.Tags {
+			counts[tag]++
+		}
+	}
+	result := make(Frequencies, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, Frequency{NCousins: count, Name: tag})
+	}
+	return result
+}
+
+// LocationsByTag returns for each tag the Frequencies of the
+// ancestral locations of the cousins with that tag.
+func (a *Ancestries) LocationsByTag() map[string]Frequencies {
+	groups := make(map[string]Ancestries)
+	for _, ancestry := range *a {
+		for tag, _ := range ancestry.Tags {
+			groups[tag] = append(groups[tag], ancestry)
+		}
+	}
+	result := make(map[string]Frequencies, len(groups))
+	for tag, group := range groups {
+		result[tag] = group.FrequenciesOfLocations(group.Locations())
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package cousins
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// matchDateLayouts are the date formats found in the match date
+// columns of matches files. Family Finder uses month/day/year,
+// spreadsheet programs often convert dates to the local format.
+var matchDateLayouts = []string{
+	"1/2/2006",
+	"1/2/2006 15:04",
+	"1/2/2006 3:04:05 PM",
+	"1/2/06",
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2.1.2006",
+	"2 Jan 2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+}
+
+// parseMatchDate parses the date of a match. The zero time is
+// returned if the date is empty or has an unknown format.
+func parseMatchDate(date string) time.Time {
+	date = strings.TrimSpace(date)
+	if date == "" {
+		return time.Time{}
+	}
+	for _, layout := range matchDateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Period is a time period of a timeline with the matches
+// that were found during this period.
+type Period struct {
+	// Start is the beginning of the period.
+	Start time.Time
+	// Ancestries are the matches of the period.
+	Ancestries Ancestries
+}
+
+// Label returns the period as text, for example "2014" for a year
+// or "2014-09" for a month.
+func (p *Period) Label(byYear bool) string {
+	if byYear {
+		return p.Start.Format("2006")
+	}
+	return p.Start.Format("2006-01")
+}
+
+// Timeline groups the Ancestries by the year or month of their
+// match date. The periods are returned in chronological order.
+// Periods without matches between the first and last period are
+// included, so that the periods can be drawn as a chart.
+// Ancestries without a match date are returned separately.
+func (a *Ancestries) Timeline(byYear bool) (periods []Period, undated Ancestries) {
+	start := func(t time.Time) time.Time {
+		if byYear {
+			return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		}
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	buckets := make(map[time.Time]Ancestries)
+	for _, ancestry := range *a {
+		if ancestry.MatchDate.IsZero() {
+			undated = append(undated, ancestry)
+			continue
+		}
+		key := start(ancestry.MatchDate)
+		buckets[key] = append(buckets[key], ancestry)
+	}
+	if len(buckets) == 0 {
+		return nil, undated
+	}
+	keys := make([]time.Time, 0, len(buckets))
+	for key, _ := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Before(keys[j]) })
+	last := keys[len(keys)-1]
+	for t := keys[0]; !t.After(last); {
+		periods = append(periods, Period{Start: t, Ancestries: buckets[t]})
+		if byYear {
+			t = t.AddDate(1, 0, 0)
+		} else {
+			t = t.AddDate(0, 1, 0)
+		}
+	}
+	return periods, undated
+}
@@ -0,0 +1,256 @@
+package cousins
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SharedMatches contains for each match the matches that are
+// in common with it (ICW). Names are stored in small caps.
+type SharedMatches map[string]map[string]bool
+
+// ReadSharedMatches reads a file in CSV format that lists pairs of
+// matches who also match each other, one pair per row. The columns
+// are found by captions like "Match Name" and "ICW Name", as used by
+// DNAGedcom, otherwise the first two columns are used.
+// encoding is the character encoding of the file.
+func ReadSharedMatches(filename string, encoding string) (SharedMatches, error) {
+	records, err := readCSV(filename, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty file")
+	}
+	cols := newColumns(records[0])
+	matchCol := cols.index("matchname", "match name", "match")
+	icwCol := cols.index("icwname", "icw name", "icw", "in common with", "shared match")
+	rows := records[1:]
+	if matchCol < 0 || icwCol < 0 {
+		// No captions, so the first row contains data.
+		matchCol, icwCol, rows = 0, 1, records
+	}
+	result := make(SharedMatches)
+	for i, row := range rows {
+		a, b := field(row, matchCol), field(row, icwCol)
+		if a == "" || b == "" {
+			return nil, fmt.Errorf("line %d of %s: two match names required", i+1, filename)
+		}
+		result.add(a, b)
+	}
+	return result, nil
+}
+
+// add stores that the matches a and b match each other.
+func (s SharedMatches) add(a, b string) {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if s[a] == nil {
+		s[a] = make(map[string]bool)
+	}
+	if s[b] == nil {
+		s[b] = make(map[string]bool)
+	}
+	s[a][b] = true
+	s[b][a] = true
+}
+
+// Shared reports whether the matches a and b match each other.
+func (s SharedMatches) Shared(a, b string) bool {
+	return s[strings.ToLower(a)][strings.ToLower(b)]
+}
+
+// TriangulationGroup is a group of at least three matches who share
+// overlapping segments and who all match each other. Such a group
+// is evidence for a common ancestral couple.
+type TriangulationGroup struct {
+	Chromosome string
+	// Start and End are the region shared by all segments of the group.
+	Start, End int
+	// Segments contains one segment of each match of the group.
+	Segments []Segment
+}
+
+// Matches returns the names of the matches of the group.
+func (g *TriangulationGroup) Matches() []string {
+	result := make([]string, len(g.Segments))
+	for i, segment := range g.Segments {
+		result[i] = segment.Match
+	}
+	return result
+}
+
+// CommonAncestry returns the Ancestries of the matches of the group who
+// provide ancestral information, that is surnames or locations, and the
+// surnames and locations common to all of them. Matches without ancestral
+// information are ignored, so that they do not empty the intersection.
+// names and locations are nil if less than two matches provide information.
+func (g *TriangulationGroup) CommonAncestry(a *Ancestries) (informative Ancestries, names, locations map[string]bool) {
+	var elements []Ancestries
+	for _, match := range g.Matches() {
+		for _, ancestry := range a.WithMatches([]string{match}) {
+			if len(ancestry.Names) > 0 || len(ancestry.Locations) > 0 {
+				informative = append(informative, ancestry)
+				elements = append(elements, Ancestries{ancestry})
+			}
+		}
+	}
+	if len(elements) < 2 {
+		return informative, nil, nil
+	}
+	list := NewAncestriesListOf(elements...)
+	return informative, list.CommonNames(), list.CommonLocations()
+}
+
+// Triangulate finds the triangulation groups within segment clusters.
+// Two segments are linked if they overlap, belong to different
+// matches and the matches match each other according to shared.
+// Each group is a maximal set of pairwise linked segments with at
+// least minSize segments. Groups that are part of a larger group,
+// which is found in another cluster, are left out. The groups are
+// ordered by chromosome and position.
+func Triangulate(clusters []SegmentCluster, shared SharedMatches, minSize int) []TriangulationGroup {
+	var result []TriangulationGroup
+	for _, cluster := range clusters {
+		segments := cluster.Segments
+		n := len(segments)
+		if n < minSize {
+			continue
+		}
+		linked := make([][]bool, n)
+		for i := range linked {
+			linked[i] = make([]bool, n)
+		}
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				a, b := &segments[i], &segments[j]
+				if a.Overlaps(b) && !strings.EqualFold(a.Match, b.Match) && shared.Shared(a.Match, b.Match) {
+					linked[i][j], linked[j][i] = true, true
+				}
+			}
+		}
+		for _, clique := range maximalCliques(linked) {
+			if len(clique) < minSize {
+				continue
+			}
+			group := TriangulationGroup{Chromosome: cluster.Chromosome}
+			for k, i := range clique {
+				segment := segments[i]
+				group.Segments = append(group.Segments, segment)
+				// Overlapping intervals always share a common region.
+				if k == 0 || segment.Start > group.Start {
+					group.Start = segment.Start
+				}
+				if k == 0 || segment.End < group.End {
+					group.End = segment.End
+				}
+			}
+			result = append(result, group)
+		}
+	}
+	return withoutSubgroups(result)
+}
+
+// withoutSubgroups removes the groups whose segments are all
+// contained in another group. Of equal groups only the first is kept.
+func withoutSubgroups(groups []TriangulationGroup) []TriangulationGroup {
+	key := func(s Segment) string {
+		return fmt.Sprintf("%s|%s|%d|%d", strings.ToLower(s.Match), s.Chromosome, s.Start, s.End)
+	}
+	sets := make([]map[string]bool, len(groups))
+	for i, group := range groups {
+		sets[i] = make(map[string]bool, len(group.Segments))
+		for _, segment := range group.Segments {
+			sets[i][key(segment)] = true
+		}
+	}
+	contains := func(i, j int) bool {
+		for k, _ := range sets[j] {
+			if !sets[i][k] {
+				return false
+			}
+		}
+		return true
+	}
+	var result []TriangulationGroup
+	for j, group := range groups {
+		redundant := false
+		for i := range groups {
+			if i != j && contains(i, j) && (len(sets[i]) > len(sets[j]) || i < j) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// maximalCliques returns all maximal cliques of the graph given by
+// its adjacency matrix, using the Bron-Kerbosch algorithm with pivoting.
+// The nodes of each clique are in ascending order.
+func maximalCliques(linked [][]bool) [][]int {
+	var result [][]int
+	var search func(r, p, x []int)
+	search = func(r, p, x []int) {
+		if len(p) == 0 && len(x) == 0 {
+			clique := append([]int{}, r...)
+			sort.Ints(clique)
+			result = append(result, clique)
+			return
+		}
+		// Choose the pivot with the most neighbours in p.
+		pivot, best := -1, -1
+		for _, u := range append(append([]int{}, p...), x...) {
+			count := 0
+			for _, v := range p {
+				if linked[u][v] {
+					count++
+				}
+			}
+			if count > best {
+				pivot, best = u, count
+			}
+		}
+		for _, v := range append([]int{}, p...) {
+			if linked[pivot][v] {
+				continue
+			}
+			search(append(r, v), neighbours(linked, v, p), neighbours(linked, v, x))
+			p = remove(p, v)
+			x = append(x, v)
+		}
+	}
+	all := make([]int, len(linked))
+	for i := range all {
+		all[i] = i
+	}
+	search(nil, all, nil)
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+	return result
+}
+
+// neighbours returns the nodes of set that are linked to v.
+func neighbours(linked [][]bool, v int, set []int) []int {
+	var result []int
+	for _, u := range set {
+		if linked[v][u] {
+			result = append(result, u)
+		}
+	}
+	return result
+}
+
+// remove returns set without v.
+func remove(set []int, v int) []int {
+	result := make([]int, 0, len(set))
+	for _, u := range set {
+		if u != v {
+			result = append(result, u)
+		}
+	}
+	return result
+}
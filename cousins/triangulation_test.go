@@ -0,0 +1,80 @@
+package cousins
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommonAncestryIgnoresEmptyMembers(t *testing.T) {
+	anna := NewAncestry("Schmidt (Hessen, Deutschland)")
+	anna.Match = "Anna"
+	carl := NewAncestry("")
+	carl.Match = "Carl"
+	dora := NewAncestry("Schmidt (Hessen) / Weber (Bayern)")
+	dora.Match = "Dora"
+	ancestries := Ancestries{anna, carl, dora}
+	group := TriangulationGroup{Chromosome: "5", Segments: []Segment{
+		{Match: "Anna"}, {Match: "Carl"}, {Match: "Dora"},
+	}}
+
+	informative, names, locations := group.CommonAncestry(&ancestries)
+	if len(informative) != 2 {
+		t.Errorf("got %d informative matches, want 2", len(informative))
+	}
+	if want := map[string]bool{"schmidt": true}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	if want := map[string]bool{"hesse": true, "germany": true}; !reflect.DeepEqual(locations, want) {
+		t.Errorf("locations = %v, want %v", locations, want)
+	}
+}
+
+func TestTriangulate(t *testing.T) {
+	shared := make(SharedMatches)
+	shared.add("Anna", "Hans")
+	shared.add("Anna", "Mary")
+	shared.add("Hans", "Mary")
+	segments := []Segment{
+		{Match: "Anna", Chromosome: "5", Start: 100, End: 300},
+		{Match: "Hans", Chromosome: "5", Start: 250, End: 400},
+		{Match: "Mary", Chromosome: "5", Start: 260, End: 500},
+		{Match: "John", Chromosome: "5", Start: 270, End: 280},
+	}
+	groups := Triangulate(ClusterSegments(segments), shared, 3)
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	g := groups[0]
+	if want := []string{"Anna", "Hans", "Mary"}; !reflect.DeepEqual(g.Matches(), want) {
+		t.Errorf("matches = %v, want %v", g.Matches(), want)
+	}
+	if g.Start != 260 || g.End != 300 {
+		t.Errorf("region = %d-%d, want 260-300", g.Start, g.End)
+	}
+}
+
+func TestTriangulateWithoutSubgroups(t *testing.T) {
+	shared := make(SharedMatches)
+	for _, pair := range [][2]string{{"A", "B"}, {"A", "C"}, {"B", "C"}, {"B", "D"}, {"C", "D"}} {
+		shared.add(pair[0], pair[1])
+	}
+	// A, B and C share 150-200, B, C and D share 250-300.
+	segments := []Segment{
+		{Match: "A", Chromosome: "5", Start: 100, End: 200},
+		{Match: "B", Chromosome: "5", Start: 150, End: 400},
+		{Match: "C", Chromosome: "5", Start: 150, End: 300},
+		{Match: "D", Chromosome: "5", Start: 250, End: 500},
+	}
+	groups := Triangulate(ClusterSegments(segments), shared, 3)
+	var got [][]string
+	for _, g := range groups {
+		got = append(got, g.Matches())
+	}
+	if want := [][]string{{"A", "B", "C"}, {"B", "C", "D"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+	groups = Triangulate(append(ClusterSegments(segments), ClusterSegments(segments)...), shared, 3)
+	if len(groups) != 2 {
+		t.Errorf("got %d groups from duplicated clusters, want 2", len(groups))
+	}
+}
@@ -0,0 +1,158 @@
+package cousins
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Years is a time period given in years, for example the life dates
+// of an ancestor. A value of 0 means unknown.
+type Years struct {
+	From int
+	To   int
+}
+
+// IsZero reports whether the period is completely unknown.
+func (y Years) IsZero() bool {
+	return y.From == 0 && y.To == 0
+}
+
+// Union returns the smallest period that contains y and o.
+func (y Years) Union(o Years) Years {
+	switch {
+	case y.IsZero():
+		return o
+	case o.IsZero():
+		return y
+	}
+	if o.From < y.From {
+		y.From = o.From
+	}
+	if o.To > y.To {
+		y.To = o.To
+	}
+	return y
+}
+
+// Overlaps reports whether the period lies at least partly between
+// after and before. A value of 0 for after or before means no limit.
+// An unknown period never overlaps.
+func (y Years) Overlaps(after, before int) bool {
+	if y.IsZero() {
+		return false
+	}
+	return (before == 0 || y.From < before) && (after == 0 || y.To > after)
+}
+
+// String returns the period in the form "1780-1850" or "1820".
+func (y Years) String() string {
+	switch {
+	case y.IsZero():
+		return ""
+	case y.From == y.To:
+		return strconv.Itoa(y.From)
+	}
+	return strconv.Itoa(y.From) + "-" + strconv.Itoa(y.To)
+}
+
+// yearPattern matches years from 1000 to 2099 including typical
+// genealogical abbreviations in front of them, like "b. 1820",
+// "abt 1800" or "d. 1850". Decades like "1780s" are also matched.
+// "ca" needs a dot, because it may also stand for California.
+var yearPattern = regexp.MustCompile(`(?:\b(?:(?:b|born|d|died|bap|bapt|m|abt|about|c|circa|bef|before|aft|after|fl)\.?|ca\.)\s*)?\b(1[0-9]{3}|20[0-9]{2})(s?)\b`)
+
+// extractYears removes all years from text and returns
+// the remaining text and the period given by the years.
+func extractYears(text string) (string, Years) {
+	var years Years
+	rest := yearPattern.ReplaceAllStringFunc(text, func(match string) string {
+		sub := yearPattern.FindStringSubmatch(match)
+		year, _ := strconv.Atoi(sub[1])
+		period := Years{year, year}
+		if sub[2] != "" {
+			// A decade like "1780s".
+			period.To = year + 9
+		}
+		years = years.Union(period)
+		return " "
+	})
+	return rest, years
+}
+
+// typicalYears returns the typical period for each key returned by
+// keyFunc for the entries of the Ancestries. From and To are the
+// medians of the beginnings and ends of the periods of all Ancestries.
+func (a *Ancestries) typicalYears(keyFunc func(Entry) []string) map[string]Years {
+	froms := make(map[string][]int)
+	tos := make(map[string][]int)
+	for _, ancestry := range *a {
+		// Each Ancestry counts once for each key.
+		periods := make(map[string]Years)
+		for _, entry := range ancestry.Entries {
+			if entry.Years.IsZero() {
+				continue
+			}
+			for _, key := range keyFunc(entry) {
+				periods[key] = periods[key].Union(entry.Years)
+			}
+		}
+		for key, years := range periods {
+			froms[key] = append(froms[key], years.From)
+			tos[key] = append(tos[key], years.To)
+		}
+	}
+	result := make(map[string]Years, len(froms))
+	for key, _ := range froms {
+		result[key] = Years{median(froms[key]), median(tos[key])}
+	}
+	return result
+}
+
+// LocationYears returns the typical period of each location.
+// Only locations with years are contained in the result.
+func (a *Ancestries) LocationYears() map[string]Years {
+	return a.typicalYears(func(entry Entry) []string { return entry.Places })
+}
+
+// NameYears returns the typical period of each surname.
+// Only surnames with years are contained in the result.
+func (a *Ancestries) NameYears() map[string]Years {
+	return a.typicalYears(func(entry Entry) []string {
+		if entry.Surname == "" {
+			return nil
+		}
+		return []string{entry.Surname}
+	})
+}
+
+// Period returns the Ancestries with at least one entry
+// whose years lie at least partly between after and before.
+// A value of 0 for after or before means no limit.
+func (a *Ancestries) Period(after, before int) Ancestries {
+	result := make(Ancestries, 0, len(*a))
+	for _, ancestry := range *a {
+		if ancestry.InPeriod(after, before) {
+			result = append(result, ancestry)
+		}
+	}
+	return result
+}
+
+// InPeriod reports whether at least one entry of the Ancestry
+// is dated between after and before. See Period.
+func (a *Ancestry) InPeriod(after, before int) bool {
+	for _, entry := range a.Entries {
+		if entry.Years.Overlaps(after, before) {
+			return true
+		}
+	}
+	return false
+}
+
+// median returns the median of values.
+func median(values []int) int {
+	sorted := append([]int{}, values...)
+	sort.Ints(sorted)
+	return sorted[len(sorted)/2]
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+// Crosstab is the cross tabulation of ancestral surnames and the
+// locations that cousins give together with them in the same entry.
+type Crosstab struct {
+	// Locations contains the surnames for each location.
+	Locations []CrosstabRow `json:"locations"`
+	// Surnames contains the locations for each surname.
+	Surnames []CrosstabRow `json:"surnames"`
+}
+
+// CrosstabRow contains the surnames linked to a location
+// or the locations linked to a surname.
+type CrosstabRow struct {
+	Name string `json:"name"`
+	// Cousins is the number of cousins with the location or surname.
+	Cousins int `json:"cousins"`
+	// Links are the linked surnames or locations in descending order.
+	Links cousins.Frequencies `json:"links"`
+}
+
+// newCrosstab creates the Crosstab of an analysis. Only links of
+// at least min cousins are contained. The rows are in descending
+// order of the number of cousins.
+func newCrosstab(a *analysis, min int) *Crosstab {
+	return &Crosstab{
+		Locations: crosstabRows(a.locationFrequencies(), a.ancestries.SurnamesByLocation(), min),
+		Surnames:  crosstabRows(a.nameFrequencies(), a.ancestries.LocationsBySurname(), min),
+	}
+}
+
+// crosstabRows creates a row for each key that has links
+// of at least min cousins.
+func crosstabRows(keys cousins.Frequencies, linked map[string]cousins.Frequencies, min int) []CrosstabRow {
+	rows := []CrosstabRow{}
+	for _, key := range keys {
+		freqs := atLeast(linked[key.Name], min)
+		if len(freqs) == 0 {
+			continue
+		}
+		sort.Stable(sort.Reverse(&freqs))
+		rows = append(rows, CrosstabRow{Name: key.Name, Cousins: key.NCousins, Links: freqs})
+	}
+	return rows
+}
+
+// print prints the surnames per location and the locations per surname.
+func (c *Crosstab) print() {
+	fmt.Print("\r\n--- Surnames per location ---\r\n")
+	fmt.Print("Location:  Surnames (Number of cousins):\r\n")
+	printCrosstabRows(c.Locations)
+	fmt.Print("\r\n--- Locations per surname ---\r\n")
+	fmt.Print("Surname:  Locations (Number of cousins):\r\n")
+	printCrosstabRows(c.Surnames)
+}
+
+func printCrosstabRows(rows []CrosstabRow) {
+	for _, row := range rows {
+		parts := make([]string, len(row.Links))
+		for i, freq := range row.Links {
+			parts[i] = fmt.Sprintf("%v (%v)", freq.Name, freq.NCousins)
+		}
+		fmt.Printf("%v: %v\r\n", row.Name, strings.Join(parts, ", "))
+	}
+}
+
+// WriteJSON writes the Crosstab in JSON format.
+func (c *Crosstab) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+// WriteCSV writes the Crosstab in CSV format with the columns
+// Location, Surname and Cousins. Each link is written once,
+// ordered by location.
+func (c *Crosstab) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+	writer.Write([]string{"Location", "Surname", "Cousins"})
+	for _, row := range c.Locations {
+		for _, freq := range row.Links {
+			writer.Write([]string{row.Name, freq.Name, strconv.Itoa(freq.NCousins)})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
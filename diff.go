@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var diffCommand = &command{
+	name:  "diff",
+	usage: "[options] <old matches file> <new matches file>",
+	short: "Shows the differences between two downloads of the same matches file.",
+	long: "Diff compares an older and a newer download of the same kit's matches file.\r\n" +
+		"It lists new matches, removed matches and matches whose ancestral information\r\n" +
+		"changed. It also shows which countries, locations and surnames gained cousins.\r\n" +
+		"With -min only gains of at least <min> cousins are shown.",
+	run: runDiff,
+}
+
+func runDiff(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	if len(files) != 2 {
+		return errors.New("diff needs exactly two input files, the old and the new one")
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+
+	previous, err := opts.loadAncestries(files[0])
+	if err != nil {
+		return err
+	}
+	current, err := opts.loadAncestries(files[1])
+	if err != nil {
+		return err
+	}
+	printFilters(&opts)
+	olds := newAnalysis(previous)
+	olds.filter(&opts)
+	news := newAnalysis(current)
+	news.filter(&opts)
+
+	diff := cousins.NewDiff(olds.ancestries, news.ancestries)
+	fmt.Printf("Comparing %v (%d cousins) with %v (%d cousins).\r\n\r\n",
+		files[0], len(olds.ancestries), files[1], len(news.ancestries))
+
+	fmt.Printf("--- New matches: %d ---\r\n", len(diff.Added))
+	printMatches(diff.Added)
+	fmt.Printf("\r\n--- Removed matches: %d ---\r\n", len(diff.Removed))
+	printMatches(diff.Removed)
+	fmt.Printf("\r\n--- Matches with changed ancestral information: %d ---\r\n", len(diff.Changed))
+	for _, change := range diff.Changed {
+		fmt.Printf("%v\r\n", change.New.Match)
+		fmt.Printf("  old: %v\r\n", change.Old.Line())
+		fmt.Printf("  new: %v\r\n", change.New.Line())
+		if added := change.AddedNames(); len(added) > 0 {
+			fmt.Printf("  added surnames: %v\r\n", strings.Join(added, ", "))
+		}
+		if removed := change.RemovedNames(); len(removed) > 0 {
+			fmt.Printf("  removed surnames: %v\r\n", strings.Join(removed, ", "))
+		}
+	}
+
+	fmt.Print("\r\n--- Countries that gained cousins ---\r\n")
+	fmt.Print("Gain:  Now:  Ancestry from:\r\n")
+	printGains(olds.countryFrequencies(), news.countryFrequencies(), opts.min)
+
+	// Locations and names of both snapshots are evaluated,
+	// so that the frequencies can be compared.
+	olds.locations = unionOf(olds.locations, news.locations)
+	news.locations = olds.locations
+	olds.names = unionOf(olds.names, news.names)
+	news.names = olds.names
+	fmt.Print("\r\n--- Locations that gained cousins ---\r\n")
+	fmt.Print("Gain:  Now:  Ancestry from:\r\n")
+	printGains(olds.locationFrequencies(), news.locationFrequencies(), opts.min)
+	fmt.Print("\r\n--- Surnames that gained cousins ---\r\n")
+	fmt.Print("Gain:  Now:  Ancestral surname:\r\n")
+	printGains(olds.nameFrequencies(), news.nameFrequencies(), opts.min)
+	return nil
+}
+
+// printMatches prints the names and ancestral information of matches.
+func printMatches(ancestries cousins.Ancestries) {
+	for _, ancestry := range ancestries {
+		match := ancestry.Match
+		if match == "" {
+			match = "(unknown)"
+		}
+		fmt.Printf("%v: %v\r\n", match, ancestry.Line())
+	}
+}
+
+// printGains prints the frequencies that increased by at least min
+// cousins from previous to current, starting with the largest gain.
+func printGains(previous, current cousins.Frequencies, min int) {
+	before := make(map[string]int, len(previous))
+	for _, freq := range previous {
+		before[freq.Name] = freq.NCousins
+	}
+	var gains cousins.Frequencies
+	var nows []int
+	for _, freq := range current {
+		if gain := freq.NCousins - before[freq.Name]; gain > 0 && gain >= min {
+			gains = append(gains, cousins.Frequency{Name: freq.Name, NCousins: gain})
+			nows = append(nows, freq.NCousins)
+		}
+	}
+	order := make([]int, len(gains))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return gains[order[i]].NCousins > gains[order[j]].NCousins
+	})
+	for _, i := range order {
+		fmt.Printf("+%v %v %v\r\n", gains[i].NCousins, nows[i], gains[i].Name)
+	}
+}
+
+// unionOf returns a set containing the elements of a and b.
+func unionOf(a, b map[string]bool) map[string]bool {
+	result := make(map[string]bool, len(a)+len(b))
+	for key, _ := range a {
+		result[key] = true
+	}
+	for key, _ := range b {
+		result[key] = true
+	}
+	return result
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var exportCommand = &command{
+	name:  "export",
+	usage: "[options] <matches file>...",
+	short: "Writes the results of an analysis in CSV or JSON format.",
+	long: "Export writes the frequencies of countries, locations and surnames to a file.\r\n" +
+		"Several input files are united. The formats are:\r\n" +
+		"  csv      all frequencies with the columns Type, Name and Cousins\r\n" +
+		"  json     all frequencies as a JSON object\r\n" +
+		"  heatmap  countries and US states for creating heat maps\r\n" +
+		"With -crosstab the surnames per location are written instead of the frequencies.\r\n" +
+		"With -testers csv and json also contain the cousins per million testers of the countries.",
+	run: runExport,
+}
+
+// Report contains the results of an analysis.
+type Report struct {
+	// Cousins is the number of analysed cousins.
+	Cousins   int                 `json:"cousins"`
+	Countries cousins.Frequencies `json:"countries"`
+	Locations cousins.Frequencies `json:"locations"`
+	Surnames  cousins.Frequencies `json:"surnames"`
+	// YHaplogroups and MtHaplogroups are the haplogroups of the cousins.
+	YHaplogroups  cousins.Frequencies `json:"yHaplogroups"`
+	MtHaplogroups cousins.Frequencies `json:"mtHaplogroups"`
+	// Tags are the tags in the notes of the cousins.
+	Tags cousins.Frequencies `json:"tags"`
+	// CountryRates contains the cousins per million testers of the
+	// countries with a known number of testers. It is only filled
+	// if the number of testers per country is given.
+	CountryRates map[string]float64 `json:"countryRates,omitempty"`
+}
+
+// newReport creates a Report that contains only frequencies
+// that occur at least min times. population may be nil.
+func newReport(a *analysis, min int, population cousins.Population) Report {
+	countries := atLeast(a.countryFrequencies(), min)
+	return Report{
+		Cousins:   len(a.ancestries),
+		Countries: countries,
+		Locations: atLeast(a.locationFrequencies(), min),
+		Surnames:  atLeast(a.nameFrequencies(), min),
+
+		YHaplogroups:  atLeast(a.yHaplogroupFrequencies(), min),
+		MtHaplogroups: atLeast(a.mtHaplogroupFrequencies(), min),
+		Tags:          atLeast(a.tagFrequencies(), min),
+
+		CountryRates: perMillion(countries, population),
+	}
+}
+
+// perMillion returns the cousins per million testers for the
+// frequencies with a known number of testers. The result is nil
+// if population is nil.
+func perMillion(freqs cousins.Frequencies, population cousins.Population) map[string]float64 {
+	if population == nil {
+		return nil
+	}
+	result := make(map[string]float64)
+	for _, freq := range freqs {
+		if testers, ok := population.Count(freq.Name); ok {
+			result[freq.Name] = 1e6 * float64(freq.NCousins) / testers
+		}
+	}
+	return result
+}
+
+// atLeast returns the frequencies that occur at least min times.
+func atLeast(freqs cousins.Frequencies, min int) cousins.Frequencies {
+	result := make(cousins.Frequencies, 0, len(freqs))
+	for _, freq := range freqs {
+		if freq.NCousins >= min {
+			result = append(result, freq)
+		}
+	}
+	return result
+}
+
+// WriteJSON writes the Report in JSON format.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes all frequencies of the Report in CSV format.
+// If the Report contains CountryRates, the column PerMillion
+// contains the cousins per million testers of the countries.
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+	caption := []string{"Type", "Name", "Cousins"}
+	if r.CountryRates != nil {
+		caption = append(caption, "PerMillion")
+	}
+	writer.Write(caption)
+	tables := []struct {
+		name  string
+		freqs cousins.Frequencies
+	}{
+		{"country", r.Countries},
+		{"location", r.Locations},
+		{"surname", r.Surnames},
+		{"y-haplogroup", r.YHaplogroups},
+		{"mt-haplogroup", r.MtHaplogroups},
+		{"tag", r.Tags},
+	}
+	for _, table := range tables {
+		for _, freq := range table.freqs {
+			row := []string{table.name, freq.Name, strconv.Itoa(freq.NCousins)}
+			if r.CountryRates != nil {
+				rate := ""
+				if value, ok := r.CountryRates[freq.Name]; ok && table.name == "country" {
+					rate = strconv.FormatFloat(value, 'f', 1, 64)
+				}
+				row = append(row, rate)
+			}
+			writer.Write(row)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func runExport(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	opts.registerTesters(fs)
+	format := fs.String("format", "csv", "Output format: csv, json or heatmap.")
+	output := fs.String("o", "", "Output file. Default is standard output.")
+	crosstab := fs.Bool("crosstab", false, "Writes the surnames per location and the locations per surname in CSV or JSON format.")
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	if len(files) == 0 {
+		return errors.New("no input filename specified")
+	}
+	switch *format {
+	case "csv", "json", "heatmap":
+	default:
+		return fmt.Errorf("invalid value %q for -format, must be csv, json or heatmap", *format)
+	}
+	if *format == "heatmap" && *crosstab {
+		return errors.New("crosstab can not be written in heatmap format")
+	}
+	if *format == "heatmap" && *output == "" {
+		return errors.New("heatmap format needs an output file, use -o")
+	}
+	if err := checkOutputFile(*output, files); err != nil {
+		return err
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+
+	// Load and filter ancestral information.
+	var ancestries cousins.Ancestries
+	if len(files) == 1 {
+		var err error
+		ancestries, err = opts.loadAncestries(files[0])
+		if err != nil {
+			return err
+		}
+	} else {
+		list, err := opts.loadList(files)
+		if err != nil {
+			return err
+		}
+		ancestries = list.Unite()
+	}
+	a := newAnalysis(ancestries)
+	a.filter(&opts)
+
+	if *format == "heatmap" {
+		regionFreqs := a.heatmapFrequencies()
+		return regionFreqs.WriteCSV(*output)
+	}
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		outfile, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer outfile.Close()
+		w = outfile
+	}
+	if *crosstab {
+		ct := newCrosstab(a, opts.min)
+		if *format == "json" {
+			return ct.WriteJSON(w)
+		}
+		return ct.WriteCSV(w)
+	}
+	report := newReport(a, opts.min, opts.population)
+	if *format == "json" {
+		return report.WriteJSON(w)
+	}
+	return report.WriteCSV(w)
+}
@@ -6,215 +6,113 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"sort"
 	"strings"
-
-	"github.com/yogischogi/familyties/cousins"
 )
 
-func main() {
-	var (
-		// Command line options
-		namescol             = flag.Int("namescol", 12, "Column number for cousin names in CSV file.")
-		details              = flag.Bool("details", false, "Performs detailed analysis for locations and surnames.")
-		min                  = flag.Int("min", 1, "Prints only locations and names that occur at least <min> times.")
-		cluster              = flag.String("cluster", "", "Performs cluster analysis on the cousins who's ancestral surnames or locations match <cluster>.")
-		exclude              = flag.String("exclude", "", "Excludes cousins who's ancestral surnames or locations match <exclude>.")
-		csvout               = flag.String("csvout", "", "Writes countries and frequencies of cousins to a file in CSV format.")
-		unite                = flag.String("unite", "", "Merges input files separated by commas.")
-		intersect            = flag.String("intersect", "", "Intersects input files separated by commas.")
-		intersectbynalo      = flag.String("intersectbynalo", "", "Intersects input files separated by commas looking for common names and locations.")
-		intersectbynames     = flag.String("intersectbynames", "", "Intersects input files separated by commas.")
-		intersectbylocations = flag.String("intersectbylocations", "", "Intersects input files separated by commas.")
-	)
-	flag.Parse()
-
-	var (
-		names                map[string]bool
-		locations            map[string]bool
-		filename             string
-		ancestries           cousins.Ancestries
-		args                 = flag.Args()
-		locationsIntersected = false
-		definedCountries     map[string]bool
-		err                  error
-	)
+// command is a subcommand of the familyties program.
+type command struct {
+	// name is used to invoke the command.
+	name string
+	// usage shows the arguments of the command.
+	usage string
+	// short is a one line description for the program's help.
+	short string
+	// long is a detailed description for the command's help.
+	long string
+	// run executes the command with the arguments following its name.
+	run func(cmd *command, args []string) error
+}
 
-	// Select between options that are exclusive to each other.
-	switch {
-	case len(args) > 0:
-		filename = args[len(args)-1]
-		ancestries, err = cousins.NewAncestries(filename, *namescol-1)
-		if err != nil {
-			fmt.Printf("Error reading Family Finder matches CSV file %v.\n", err)
-			os.Exit(1)
-		}
-		names = ancestries.Names()
-		locations = ancestries.Locations()
-	case *unite != "":
-		ancestriesList, err := cousins.NewAncestriesList(*unite, *namescol-1)
-		if err != nil {
-			fmt.Printf("Error reading Family Finder matches CSV file %v.\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("Uniting files %v.\r\n\r\n", *unite)
-		ancestries = ancestriesList.Unite()
-		names = ancestries.Names()
-		locations = ancestries.Locations()
-	case *intersect != "":
-		ancestriesList, err := cousins.NewAncestriesList(*intersect, *namescol-1)
-		if err != nil {
-			fmt.Printf("Error reading Family Finder matches CSV file %v.\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("Intersecting files %v, looking for identical ancestral information.\r\n\r\n", *intersect)
-		ancestries = ancestriesList.Intersect()
-		names = ancestriesList.CommonNames()
-		locations = ancestriesList.CommonLocations()
-		locationsIntersected = true
-	case *intersectbynalo != "":
-		ancestriesList, err := cousins.NewAncestriesList(*intersectbynalo, *namescol-1)
-		if err != nil {
-			fmt.Printf("Error reading Family Finder matches CSV file %v.\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("Intersecting files %v, looking for common names and locations.\r\n\r\n", *intersectbynalo)
-		ancestries = ancestriesList.IntersectByNamesAndLocations()
-		names = ancestriesList.CommonNames()
-		locations = ancestriesList.CommonLocations()
-		locationsIntersected = true
-	case *intersectbynames != "":
-		ancestriesList, err := cousins.NewAncestriesList(*intersectbynames, *namescol-1)
-		if err != nil {
-			fmt.Printf("Error reading Family Finder matches CSV file %v.\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("Intersecting files %v, looking for common names.\r\n\r\n", *intersectbynames)
-		ancestries = ancestriesList.IntersectByNames()
-		names = ancestriesList.CommonNames()
-		locations = ancestries.Locations()
-	case *intersectbylocations != "":
-		ancestriesList, err := cousins.NewAncestriesList(*intersectbylocations, *namescol-1)
-		if err != nil {
-			fmt.Printf("Error reading Family Finder matches CSV file %v.\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("Intersecting files %v, looking for common locations.\r\n\r\n", *intersectbylocations)
-		ancestries = ancestriesList.IntersectByLocations()
-		names = ancestries.Names()
-		locations = ancestriesList.CommonLocations()
-		locationsIntersected = true
-	default:
-		fmt.Print("No input filename specified.\r\n")
-		os.Exit(1)
-	}
+// commands contains all subcommands in the order of the help text.
+var commands = []*command{
+	reportCommand,
+	uniteCommand,
+	intersectCommand,
+	exportCommand,
+	diffCommand,
+	timelineCommand,
+	xmatchCommand,
+	segmentsCommand,
+	triangulateCommand,
+	proportionsCommand,
+	shellCommand,
+	serveCommand,
+}
 
-	// Remove all elements from PredefinedCountries that were
-	// eliminated due to an intersection operation.
-	switch locationsIntersected {
-	case true:
-		definedCountries = make(map[string]bool)
-		for country, _ := range PredefinedCountries() {
-			if locations[strings.ToLower(country)] {
-				definedCountries[country] = true
-			}
-		}
-	case false:
-		definedCountries = PredefinedCountries()
+// flagSet creates a FlagSet for the command which prints
+// the command's help.
+func (cmd *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: familyties %s %s\r\n\r\n", cmd.name, cmd.usage)
+		fmt.Fprintf(os.Stderr, "%s\r\n\r\nOptions:\r\n", cmd.long)
+		fs.PrintDefaults()
 	}
+	return fs
+}
 
-	// Exclude
-	if *exclude != "" {
-		fmt.Printf("Cousins who's ancestral surnames or locations match %v are excluded from analysis.\r\n\r\n", *exclude)
-		excludes := strings.Split(*exclude, ",")
-		for _, name := range excludes {
-			name = strings.TrimSpace(name)
-			ancestries = ancestries.Exclude(name)
+// findCommand returns the command with the given name or nil.
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
 		}
 	}
+	return nil
+}
 
-	// Filter ancestral information for cluster analysis.
-	if *cluster != "" {
-		fmt.Printf("Cluster analysis for %v.\r\n\r\n", *cluster)
-		includes := strings.Split(*cluster, ",")
-		var newAncestries cousins.Ancestries
-		rest := ancestries
-		for _, name := range includes {
-			name = strings.TrimSpace(name)
-			newElements := rest.Include(name)
-			rest = rest.Exclude(name)
-			newAncestries = append(newAncestries, newElements...)
-		}
-		ancestries = newAncestries
-	}
-	if len(ancestries) == 0 {
-		fmt.Print("No data found.\r\n")
-		os.Exit(0)
+func usage() {
+	fmt.Fprint(os.Stderr, "Usage: familyties <command> [options] <files>\r\n\r\nCommands:\r\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\r\n", cmd.name, cmd.short)
 	}
+	fmt.Fprint(os.Stderr, "\r\nUse \"familyties help <command>\" for more information about a command.\r\n")
+	fmt.Fprint(os.Stderr, "If no command is given, report is used.\r\n")
+}
 
-	// Quick analysis for predefined countries.
-	countries := ancestries.FrequenciesOf(definedCountries)
-	sort.Stable(sort.Reverse(&countries))
-	fmt.Print("--- Quick search for predefined countries ---\r\n")
-	fmt.Print("Number of cousins:  Ancestry from:\r\n")
-	for i := 0; i < countries.Len(); i++ {
-		if countries[i].NCousins >= *min {
-			fmt.Printf("%v %v\r\n", countries[i].NCousins, countries[i].Name)
-		}
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		usage()
+		os.Exit(1)
 	}
 
-	// Write countries and frequencies of cousins to a file in CSV format.
-	if *csvout != "" {
-		if *csvout == filename {
-			fmt.Print("Error, CSV filename identical to file containing family data.\r\n")
-		} else {
-			// Create a list of heatmap locations.
-			// US and USA are substituted by US state names.
-			locations := make(map[string]bool)
-			for country, _ := range definedCountries {
-				locations[country] = true
-			}
-			for _, state := range usStates {
-				locations[state] = true
-			}
-			delete(locations, "US")
-			delete(locations, "USA")
-
-			// Calculate frequencies of cousins.
-			regionFreqs := ancestries.FrequenciesOf(locations)
-			sort.Stable(sort.Reverse(&regionFreqs))
-
-			// Write result to file.
-			err := regionFreqs.WriteCSV(*csvout)
-			if err != nil {
-				fmt.Printf("Error writing countries to file in CSV format, %v.\r\n", err)
-			}
+	// Select command. Invocations without a command name,
+	// like "familyties -details file.csv", create a report.
+	var cmd *command
+	switch name := args[0]; {
+	case name == "help" || name == "-help" || name == "--help" || name == "-h":
+		if len(args) > 1 && findCommand(args[1]) != nil {
+			// The command's FlagSet prints the help and exits.
+			cmd = findCommand(args[1])
+			cmd.run(cmd, []string{"-help"})
 		}
-	}
-
-	if *details == false {
+		usage()
 		os.Exit(0)
+	case findCommand(name) != nil:
+		cmd = findCommand(name)
+		args = args[1:]
+	default:
+		cmd = reportCommand
 	}
 
-	// Detailed analysis of ancestral locations.
-	locFreqs := ancestries.FrequenciesOfLocations(locations)
-	sort.Stable(sort.Reverse(&locFreqs))
-	fmt.Print("\r\n--- Detailed analysis of ancestral locations ---\r\n")
-	fmt.Print("Number of cousins:  Ancestry from:\r\n")
-	for i := 0; i < locFreqs.Len(); i++ {
-		if locFreqs[i].NCousins >= *min {
-			fmt.Printf("%v %v\r\n", locFreqs[i].NCousins, locFreqs[i].Name)
-		}
+	if err := cmd.run(cmd, args); err != nil {
+		fmt.Printf("Error, %v.\r\n", err)
+		os.Exit(1)
 	}
+}
 
-	// Detailed analysis of ancestral surnames.
-	nameFreqs := ancestries.FrequenciesOfNames(names)
-	sort.Stable(sort.Reverse(&nameFreqs))
-	fmt.Print("\r\n--- Detailed analysis of ancestral surnames ---\r\n")
-	fmt.Print("Number of cousins:  Ancestral surname:\r\n")
-	for i := 0; i < nameFreqs.Len(); i++ {
-		if nameFreqs[i].NCousins >= *min {
-			fmt.Printf("%v %v\r\n", nameFreqs[i].NCousins, nameFreqs[i].Name)
+// inputFiles returns the input filenames given as arguments.
+// Filenames may also be separated by commas.
+func inputFiles(args []string) []string {
+	var result []string
+	for _, arg := range args {
+		for _, filename := range strings.Split(arg, ",") {
+			filename = strings.TrimSpace(filename)
+			if filename != "" {
+				result = append(result, filename)
+			}
 		}
 	}
+	return result
 }
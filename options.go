@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+// options are the command line options shared by the commands
+// that analyse matches files.
+type options struct {
+	namescol  int
+	encoding  string
+	nocache   bool
+	details   bool
+	crosstab  bool
+	hgs       bool
+	tags      bool
+	min       int
+	cluster   string
+	exclude   string
+	after     int
+	before    int
+	yhg       string
+	mthg      string
+	xmatch    bool
+	notes     string
+	gedcom    string
+	gedcommap string
+	tree      string
+	bootstrap int
+	testers   string
+
+	// trees are the family trees of matches, loaded by loadTrees.
+	trees []cousins.Ancestry
+	// cache stores parsed input files, may be nil.
+	cache *cousins.Cache
+	// ownTree is the user's own family tree from the -tree option,
+	// loaded by prepare. It is nil if not given.
+	ownTree *cousins.Ancestry
+	// population contains the number of testers per country,
+	// loaded from the -testers file. It is nil if not given.
+	population cousins.Population
+}
+
+// register defines the options for reading and filtering
+// matches files in the FlagSet fs.
+func (o *options) register(fs *flag.FlagSet) {
+	o.registerInput(fs)
+	fs.IntVar(&o.min, "min", 1, "Prints only locations and names that occur at least <min> times.")
+	fs.StringVar(&o.cluster, "cluster", "", "Performs cluster analysis on the cousins who's ancestral surnames or locations match <cluster>.")
+	fs.StringVar(&o.exclude, "exclude", "", "Excludes cousins who's ancestral surnames or locations match <exclude>.")
+	fs.IntVar(&o.after, "after", 0, "Analyses only cousins with ancestral surnames or locations dated after the year <after>.")
+	fs.IntVar(&o.before, "before", 0, "Analyses only cousins with ancestral surnames or locations dated before the year <before>.")
+	fs.StringVar(&o.yhg, "yhg", "", "Analyses only cousins with the Y-DNA haplogroups <yhg>. A trailing * matches all subclades, for example R-U106*.")
+	fs.StringVar(&o.notes, "notes", "", "Analyses only cousins whose notes contain <notes>.")
+	fs.BoolVar(&o.xmatch, "xmatch", false, "Analyses only cousins who also share DNA on the X chromosome.")
+	fs.StringVar(&o.mthg, "mthg", "", "Analyses only cousins with the mtDNA haplogroups <mthg>. A trailing * matches all subclades, for example H* matches H1a but not HV.")
+}
+
+// registerInput defines the options for reading matches files
+// in the FlagSet fs.
+func (o *options) registerInput(fs *flag.FlagSet) {
+	// Commands without the -min option show all frequencies.
+	o.min = 1
+	fs.IntVar(&o.namescol, "namescol", 12, "Column number for cousin names in CSV file.")
+	fs.StringVar(&o.encoding, "encoding", "auto", "Character encoding of input files: auto, utf-8, utf-16le, utf-16be, windows-1252 or latin-1.")
+	fs.BoolVar(&o.nocache, "nocache", false, "Parses input files again instead of using cached results.")
+	fs.StringVar(&o.gedcom, "gedcom", "", "Links family trees in GEDCOM files separated by commas to the matches with the same name as the tree's first person.")
+	fs.StringVar(&o.gedcommap, "gedcommap", "", "Links family trees in GEDCOM format to matches using a CSV file with the columns match name, GEDCOM file and optionally root person.")
+}
+
+// registerReport defines the options for printed reports
+// in the FlagSet fs.
+func (o *options) registerReport(fs *flag.FlagSet) {
+	fs.BoolVar(&o.details, "details", false, "Performs detailed analysis for locations and surnames.")
+	fs.BoolVar(&o.crosstab, "crosstab", false, "Shows the surnames per location and the locations per surname.")
+	fs.BoolVar(&o.tags, "tags", false, "Shows the tags like #paternal found in the notes. With -crosstab also their locations.")
+	fs.BoolVar(&o.hgs, "haplogroups", false, "Shows the Y-DNA and mtDNA haplogroups of the cousins. With -crosstab also their locations.")
+	fs.IntVar(&o.bootstrap, "bootstrap", 0, "Resamples the cousins <bootstrap> times to show confidence intervals and rank stability with -details.")
+	o.registerTesters(fs)
+	fs.StringVar(&o.tree, "tree", "", "Ranks cousins by the surnames and locations they share with the family tree in the specified GEDCOM file.")
+}
+
+// registerTesters defines the option for the number of testers
+// per country in the FlagSet fs.
+func (o *options) registerTesters(fs *flag.FlagSet) {
+	fs.StringVar(&o.testers, "testers", "", "CSV file with the columns country and testers. Shows the cousins per million testers or corrects proportions for them.")
+}
+
+// prepare validates the options and loads the family trees
+// of matches. It must be called before loading matches files.
+func (o *options) prepare() error {
+	if o.namescol < 1 {
+		return fmt.Errorf("invalid column number %d for -namescol", o.namescol)
+	}
+	if o.after != 0 && o.before != 0 && o.after >= o.before {
+		return fmt.Errorf("invalid period, -after %d must be less than -before %d", o.after, o.before)
+	}
+	if o.min < 1 {
+		return fmt.Errorf("invalid value %d for -min, must be at least 1", o.min)
+	}
+	if o.bootstrap < 0 {
+		return fmt.Errorf("invalid value %d for -bootstrap, must not be negative", o.bootstrap)
+	}
+	if o.bootstrap > 0 && !o.details {
+		return fmt.Errorf("-bootstrap needs -details")
+	}
+
+	// Cache parsed input files unless disabled. Without a usable
+	// cache directory the files are simply parsed every time.
+	if !o.nocache {
+		if dir, err := cousins.DefaultCacheDir(); err == nil {
+			o.cache, _ = cousins.NewCache(dir)
+		}
+	}
+
+	// Read family trees of matches.
+	trees, err := loadTrees(o.gedcom, o.gedcommap, o.encoding)
+	if err != nil {
+		return fmt.Errorf("reading family tree %v", err)
+	}
+	o.trees = trees
+
+	// Read own family tree.
+	if o.tree != "" {
+		ownTree, err := cousins.ReadGEDCOM(o.tree, o.encoding)
+		if err != nil {
+			return fmt.Errorf("reading family tree %v", err)
+		}
+		ownAncestry, err := ownTree.Ancestry("")
+		if err != nil {
+			return fmt.Errorf("reading family tree %v", err)
+		}
+		o.ownTree = &ownAncestry
+	}
+
+	// Read number of testers per country.
+	if o.testers != "" {
+		o.population, err = cousins.ReadPopulation(o.testers, o.encoding)
+		if err != nil {
+			return fmt.Errorf("reading tester population %v", err)
+		}
+	}
+	return nil
+}
+
+// loadAncestries loads a matches file and links the family trees
+// of matches.
+func (o *options) loadAncestries(filename string) (cousins.Ancestries, error) {
+	ancestries, err := o.cache.NewAncestries(filename, o.namescol-1, o.encoding)
+	if err != nil {
+		return nil, fmt.Errorf("reading matches file %v", err)
+	}
+	for _, tree := range o.trees {
+		ancestries.Link(tree)
+	}
+	return ancestries, nil
+}
+
+// parseAncestries creates Ancestries from the content of a matches
+// file and links the family trees of matches.
+func (o *options) parseAncestries(data []byte) (cousins.Ancestries, error) {
+	ancestries, err := cousins.ParseAncestries(data, o.namescol-1, o.encoding)
+	if err != nil {
+		return nil, err
+	}
+	for _, tree := range o.trees {
+		ancestries.Link(tree)
+	}
+	return ancestries, nil
+}
+
+// loadList loads several matches files and links the family trees
+// of matches.
+func (o *options) loadList(filenames []string) (cousins.AncestriesList, error) {
+	list, err := cousins.NewAncestriesList(strings.Join(filenames, ","), o.namescol-1, o.encoding, o.cache)
+	if err != nil {
+		return list, fmt.Errorf("reading matches file %v", err)
+	}
+	for _, tree := range o.trees {
+		list.Link(tree)
+	}
+	return list, nil
+}
+
+// loadTrees reads the family trees of matches from GEDCOM files.
+// gedcoms is a comma separated list of GEDCOM files. Each tree is
+// linked to the match with the same name as the first person in the
+// tree. mapping is the name of a CSV file that links matches to
+// GEDCOM files explicitly.
+func loadTrees(gedcoms, mapping, encoding string) ([]cousins.Ancestry, error) {
+	var result []cousins.Ancestry
+	if gedcoms != "" {
+		for _, filename := range strings.Split(gedcoms, ",") {
+			filename = strings.TrimSpace(filename)
+			tree, err := cousins.ReadGEDCOM(filename, encoding)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", filename, err)
+			}
+			ancestry, err := tree.Ancestry("")
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", filename, err)
+			}
+			result = append(result, ancestry)
+		}
+	}
+	if mapping != "" {
+		links, err := cousins.ReadTreeLinks(mapping, encoding)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			tree, err := cousins.ReadGEDCOM(link.File, encoding)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", link.File, err)
+			}
+			ancestry, err := tree.Ancestry(link.Root)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", link.File, err)
+			}
+			ancestry.Match = link.Match
+			result = append(result, ancestry)
+		}
+	}
+	return result, nil
+}
+
+// checkOutputFile makes sure that an output file does not
+// overwrite one of the input files.
+func checkOutputFile(output string, inputs []string) error {
+	for _, input := range inputs {
+		if output == input {
+			return fmt.Errorf("output file %s identical to file containing family data", output)
+		}
+	}
+	return nil
+}
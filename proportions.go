@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var proportionsCommand = &command{
+	name:  "proportions",
+	usage: "[options] <matches file>",
+	short: "Estimates the ancestral proportions of countries.",
+	long: "Proportions estimates from which countries your ancestors came. Each cousin's\r\n" +
+		"countries are weighted by the share of your ancestry that your common ancestors\r\n" +
+		"represent, which is derived from the shared centimorgans or the predicted\r\n" +
+		"relationship. The 95% intervals are calculated by resampling the cousins.\r\n" +
+		"-testers corrects for the number of testers per country and -vendor compares\r\n" +
+		"the results with the ethnicity estimate of your testing company.",
+	run: runProportions,
+}
+
+func runProportions(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	resamples := fs.Int("bootstrap", 1000, "Resamples the cousins <bootstrap> times to calculate the 95% intervals.")
+	opts.registerTesters(fs)
+	vendor := fs.String("vendor", "", "Compares with the vendor's ethnicity estimate given as a list like \"England:40, Germany:35\".")
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	switch {
+	case len(files) == 0:
+		return errors.New("no input filename specified")
+	case len(files) > 1:
+		return errors.New("proportions accepts only one input file")
+	}
+	if *resamples < 0 {
+		return fmt.Errorf("invalid value %d for -bootstrap, must not be negative", *resamples)
+	}
+	vendorShares, err := parseVendorShares(*vendor)
+	if err != nil {
+		return err
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+
+	ancestries, err := opts.loadAncestries(files[0])
+	if err != nil {
+		return err
+	}
+	var correction map[string]float64
+	if opts.population != nil {
+		correction = opts.population.Corrections(PredefinedCountries())
+	}
+	printFilters(&opts)
+	a := newAnalysis(ancestries)
+	a.filter(&opts)
+	if len(a.ancestries) == 0 {
+		fmt.Print("No data found.\r\n")
+		return nil
+	}
+
+	// A fixed seed gives the same results for the same input.
+	r := rand.New(rand.NewSource(1))
+	proportions := a.ancestries.Proportions(a.countries, correction, *resamples, r)
+	if len(proportions) == 0 {
+		fmt.Print("No countries found.\r\n")
+		return nil
+	}
+	printProportions(os.Stdout, proportions, vendorShares)
+	fmt.Print("\r\n")
+	if correction != nil {
+		fmt.Print("The proportions are corrected for the number of testers per country.\r\n")
+	}
+	fmt.Print("Proportions are estimated from the countries of your cousins and show where\r\n")
+	fmt.Print("your matched ancestral lines come from, not a genetic ethnicity estimate.\r\n")
+	return nil
+}
+
+// vendorShare is a country of the vendor's ethnicity estimate.
+type vendorShare struct {
+	name    string
+	percent float64
+}
+
+// printProportions writes the estimated proportions to w and compares
+// them with the vendor's estimate if vendor is not empty.
+func printProportions(w io.Writer, proportions []cousins.Proportion, vendor map[string]vendorShare) {
+	fmt.Fprint(w, "--- Estimated ancestral proportions ---\r\n")
+	if len(vendor) == 0 {
+		fmt.Fprint(w, "Share:  [95% interval]  Country:\r\n")
+		for _, p := range proportions {
+			fmt.Fprintf(w, "%.1f%% [%.1f-%.1f%%] %v\r\n", 100*p.Share, 100*p.Low, 100*p.High, p.Name)
+		}
+		return
+	}
+
+	fmt.Fprint(w, "Share:  [95% interval]  Vendor:  Difference:  Country:\r\n")
+	shown := make(map[string]bool)
+	var totalDiff float64
+	for _, p := range proportions {
+		key := strings.ToLower(p.Name)
+		shown[key] = true
+		v := vendor[key].percent
+		totalDiff += math.Abs(100*p.Share - v)
+		mark := ""
+		if v < 100*p.Low || v > 100*p.High {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "%.1f%% [%.1f-%.1f%%] %.1f%% %+.1f%v %v\r\n", 100*p.Share, 100*p.Low, 100*p.High, v, 100*p.Share-v, mark, p.Name)
+	}
+	// Countries of the vendor's estimate without cousins.
+	var missing []string
+	for key, _ := range vendor {
+		if !shown[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	for _, key := range missing {
+		v := vendor[key]
+		totalDiff += v.percent
+		fmt.Fprintf(w, "0.0%% [0.0-0.0%%] %.1f%% %+.1f* %v\r\n", v.percent, -v.percent, v.name)
+	}
+	fmt.Fprintf(w, "\r\nTotal difference to the vendor's estimate: %.1f percentage points.\r\n", totalDiff/2)
+	fmt.Fprint(w, "* marks countries where the vendor's estimate is outside the 95% interval.\r\n")
+}
+
+// parseVendorShares parses a list of countries and percentages like
+// "England:40, Germany:35". The keys of the result are in small caps.
+func parseVendorShares(list string) (map[string]vendorShare, error) {
+	result := make(map[string]vendorShare)
+	if strings.TrimSpace(list) == "" {
+		return result, nil
+	}
+	for _, item := range strings.Split(list, ",") {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid vendor estimate %q, use country:percent", strings.TrimSpace(item))
+		}
+		name := strings.TrimSpace(parts[0])
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(parts[1]), "%"), 64)
+		if name == "" || err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid vendor estimate %q, use country:percent", strings.TrimSpace(item))
+		}
+		key := strings.ToLower(name)
+		result[key] = vendorShare{name: name, percent: result[key].percent + percent}
+	}
+	return result, nil
+}
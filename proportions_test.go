@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+func TestParseVendorShares(t *testing.T) {
+	shares, err := parseVendorShares("England:40, Germany: 35%, england:5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 2 || shares["england"].percent != 45 || shares["germany"].percent != 35 {
+		t.Errorf("shares = %v, want england 45 and germany 35", shares)
+	}
+	if shares["germany"].name != "Germany" {
+		t.Errorf("name = %q, want Germany", shares["germany"].name)
+	}
+	if shares, err := parseVendorShares(" "); err != nil || len(shares) != 0 {
+		t.Errorf("empty list: %v, %v", shares, err)
+	}
+	for _, list := range []string{"England", "England:x", ":40", "England:140", "England:-1"} {
+		if _, err := parseVendorShares(list); err == nil {
+			t.Errorf("parseVendorShares(%q) returned no error", list)
+		}
+	}
+}
+
+func TestPrintProportionsWithVendor(t *testing.T) {
+	proportions := []cousins.Proportion{
+		{Name: "England", Share: 0.6, Low: 0.5, High: 0.7},
+		{Name: "Germany", Share: 0.4, Low: 0.3, High: 0.5},
+	}
+	vendor, err := parseVendorShares("England:55, Germany:25, Ireland:20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	printProportions(&buf, proportions, vendor)
+	out := buf.String()
+	for _, want := range []string{
+		"60.0% [50.0-70.0%] 55.0% +5.0 England\r\n",
+		// The vendor's estimate for Germany is outside the interval.
+		"40.0% [30.0-50.0%] 25.0% +15.0* Germany\r\n",
+		// Countries without cousins are listed as well.
+		"0.0% [0.0-0.0%] 20.0% -20.0* Ireland\r\n",
+		"Total difference to the vendor's estimate: 20.0 percentage points.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var reportCommand = &command{
+	name:  "report",
+	usage: "[options] <matches file>",
+	short: "Analyses the ancestral information of a single matches file.",
+	long: "Report shows how many of your cousins have ancestry from which country.\r\n" +
+		"With -details it also analyses the ancestral locations and surnames.",
+	run: runReport,
+}
+
+func runReport(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	opts.registerReport(fs)
+	csvout := fs.String("csvout", "", "Writes countries and frequencies of cousins to a file in CSV format.")
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	switch {
+	case len(files) == 0:
+		return errors.New("no input filename specified")
+	case len(files) > 1:
+		return errors.New("report accepts only one input file, use unite or intersect for several files")
+	}
+	if err := checkOutputFile(*csvout, files); err != nil {
+		return err
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+
+	ancestries, err := opts.loadAncestries(files[0])
+	if err != nil {
+		return err
+	}
+	return newAnalysis(ancestries).run(&opts, *csvout)
+}
+
+// analysis contains the Ancestries to analyse and the sets of
+// names, locations and countries which are evaluated.
+type analysis struct {
+	ancestries cousins.Ancestries
+	names      map[string]bool
+	locations  map[string]bool
+	countries  map[string]bool
+
+	// index is the Index of the Ancestries the analysis was created
+	// with. It is built once, when it is first needed. positions are
+	// the positions of the current ancestries in the index.
+	index     *cousins.Index
+	positions []int
+}
+
+// newAnalysis creates an analysis for all names and locations
+// of the Ancestries and the predefined countries.
+func newAnalysis(ancestries cousins.Ancestries) *analysis {
+	return &analysis{
+		ancestries: ancestries,
+		names:      ancestries.Names(),
+		locations:  ancestries.Locations(),
+		countries:  PredefinedCountries(),
+	}
+}
+
+// newIndexedAnalysis creates an analysis like newAnalysis for the
+// Ancestries of an Index that already exists.
+func newIndexedAnalysis(index *cousins.Index) *analysis {
+	a := newAnalysis(index.Ancestries())
+	a.index, a.positions = index, index.All()
+	return a
+}
+
+// indexed returns the Index of the analysis and the positions
+// of the current Ancestries. The Index is built if necessary.
+func (a *analysis) indexed() (*cousins.Index, []int) {
+	if a.index == nil {
+		a.index = cousins.NewIndex(a.ancestries)
+		a.positions = a.index.All()
+	}
+	return a.index, a.positions
+}
+
+// selectPositions makes the Ancestries at the given positions
+// of the Index the current Ancestries.
+func (a *analysis) selectPositions(positions []int) {
+	a.positions = positions
+	a.ancestries = a.index.Select(positions)
+}
+
+// restrictCountries removes all countries that are not contained
+// in the analysis's locations. This is needed after the locations
+// have been reduced by an intersection operation.
+func (a *analysis) restrictCountries() {
+	countries := make(map[string]bool)
+	for country, _ := range a.countries {
+		if a.locations[strings.ToLower(country)] {
+			countries[country] = true
+		}
+	}
+	a.countries = countries
+}
+
+// filter applies the -exclude, -cluster, -after, -before, -yhg,
+// -mthg, -xmatch and -notes options.
+// The filters only read the posting lists of the Index and the
+// positions that are left by the previous filters.
+func (a *analysis) filter(opts *options) {
+	if opts.exclude == "" && opts.cluster == "" && opts.notes == "" && !opts.xmatch &&
+		opts.after == 0 && opts.before == 0 && opts.yhg == "" && opts.mthg == "" {
+		return
+	}
+	index, positions := a.indexed()
+	if opts.exclude != "" {
+		positions = index.ExcludeAt(positions, splitTerms(opts.exclude)...)
+	}
+	if opts.cluster != "" {
+		positions = index.IncludeAt(positions, splitTerms(opts.cluster)...)
+	}
+	if opts.notes != "" {
+		positions = index.Where(positions, func(anc *cousins.Ancestry) bool { return anc.NotesContain(opts.notes) })
+	}
+	if opts.xmatch {
+		positions = index.Where(positions, func(anc *cousins.Ancestry) bool { return anc.XMatch })
+	}
+	if opts.after != 0 || opts.before != 0 {
+		positions = index.Where(positions, func(anc *cousins.Ancestry) bool { return anc.InPeriod(opts.after, opts.before) })
+	}
+	if opts.yhg != "" || opts.mthg != "" {
+		positions = index.Where(positions, func(anc *cousins.Ancestry) bool { return anc.MatchHaplogroups(opts.yhg, opts.mthg) })
+	}
+	a.selectPositions(positions)
+}
+
+// splitTerms splits a comma separated list of surnames or locations.
+func splitTerms(list string) []string {
+	terms := strings.Split(list, ",")
+	for i, term := range terms {
+		terms[i] = strings.TrimSpace(term)
+	}
+	return terms
+}
+
+// run filters the Ancestries and prints the results.
+// If csvout is not empty, the results are also written to
+// a file for creating heat maps.
+func (a *analysis) run(opts *options, csvout string) error {
+	printFilters(opts)
+	a.filter(opts)
+	if len(a.ancestries) == 0 {
+		fmt.Print("No data found.\r\n")
+		return nil
+	}
+
+	// Quick analysis for predefined countries.
+	fmt.Print("--- Quick search for predefined countries ---\r\n")
+	if opts.population != nil {
+		fmt.Print("Number of cousins:  Per million testers:  Ancestry from:\r\n")
+		printRates(a.countryFrequencies(), opts.min, opts.population)
+	} else {
+		fmt.Print("Number of cousins:  Ancestry from:\r\n")
+		printFrequencies(a.countryFrequencies(), opts.min)
+	}
+
+	// Write countries and frequencies of cousins to a file in CSV format.
+	if csvout != "" {
+		regionFreqs := a.heatmapFrequencies()
+		err := regionFreqs.WriteCSV(csvout)
+		if err != nil {
+			fmt.Printf("Error writing countries to file in CSV format, %v.\r\n", err)
+		}
+	}
+
+	// Compare cousins with own family tree.
+	if opts.ownTree != nil {
+		a.printTreeComparison(*opts.ownTree)
+	}
+
+	if opts.crosstab {
+		crosstab := newCrosstab(a, opts.min)
+		crosstab.print()
+	}
+
+	if opts.hgs {
+		a.printHaplogroups(opts.min, opts.crosstab)
+	}
+
+	if opts.tags {
+		a.printTags(opts.min, opts.crosstab)
+	}
+
+	if !opts.details {
+		return nil
+	}
+
+	if opts.bootstrap > 0 {
+		a.printBootstrap(opts.min, opts.bootstrap)
+		return nil
+	}
+
+	// Detailed analysis of ancestral locations.
+	fmt.Print("\r\n--- Detailed analysis of ancestral locations ---\r\n")
+	fmt.Print("Number of cousins:  Ancestry from:  (Typical years:)\r\n")
+	printDatedFrequencies(a.locationFrequencies(), opts.min, a.ancestries.LocationYears())
+
+	// Detailed analysis of ancestral surnames.
+	fmt.Print("\r\n--- Detailed analysis of ancestral surnames ---\r\n")
+	fmt.Print("Number of cousins:  Ancestral surname:  (Typical years:)\r\n")
+	printDatedFrequencies(a.nameFrequencies(), opts.min, a.ancestries.NameYears())
+	return nil
+}
+
+// printBootstrap prints the detailed analysis of locations and surnames
+// with confidence intervals and rank stability from n resamples of the
+// cousins. Entries whose rank is not stable are marked with *.
+func (a *analysis) printBootstrap(min int, n int) {
+	// A fixed seed gives the same results for the same input.
+	r := rand.New(rand.NewSource(1))
+
+	fmt.Print("\r\n--- Detailed analysis of ancestral locations ---\r\n")
+	fmt.Print("Number of cousins:  [95% interval]  Stability:  Ancestry from:  (Typical years:)\r\n")
+	locFreqs := atLeast(a.locationFrequencies(), min)
+	printBootstrapFrequencies(a.ancestries.BootstrapLocations(locFreqs, n, r), a.ancestries.LocationYears())
+
+	fmt.Print("\r\n--- Detailed analysis of ancestral surnames ---\r\n")
+	fmt.Print("Number of cousins:  [95% interval]  Stability:  Ancestral surname:  (Typical years:)\r\n")
+	nameFreqs := atLeast(a.nameFrequencies(), min)
+	printBootstrapFrequencies(a.ancestries.BootstrapNames(nameFreqs, n, r), a.ancestries.NameYears())
+
+	fmt.Printf("\r\nStability is the share of %d resamples in which an entry keeps its rank\r\n", n)
+	fmt.Print("to its neighbours. * marks entries whose rank is not stable in 95% of them.\r\n")
+}
+
+// printBootstrapFrequencies prints frequencies with their confidence
+// intervals, rank stability and typical years, if known.
+func printBootstrapFrequencies(freqs []cousins.BootstrapFrequency, years map[string]cousins.Years) {
+	for _, freq := range freqs {
+		mark := ""
+		if !freq.Stable() {
+			mark = "*"
+		}
+		fmt.Printf("%v [%v-%v] %.0f%%%v %v", freq.NCousins, freq.Low, freq.High, 100*freq.Stability, mark, freq.Name)
+		if period, ok := years[freq.Name]; ok {
+			fmt.Printf(" (%v)", period)
+		}
+		fmt.Print("\r\n")
+	}
+}
+
+// countryFrequencies returns the frequencies of the predefined
+// countries in descending order.
+func (a *analysis) countryFrequencies() cousins.Frequencies {
+	countries := a.ancestries.FrequenciesOf(a.countries)
+	sort.Stable(sort.Reverse(&countries))
+	return countries
+}
+
+// locationFrequencies returns the frequencies of the ancestral
+// locations in descending order.
+func (a *analysis) locationFrequencies() cousins.Frequencies {
+	locFreqs := a.ancestries.FrequenciesOfLocations(a.locations)
+	sort.Stable(sort.Reverse(&locFreqs))
+	return locFreqs
+}
+
+// nameFrequencies returns the frequencies of the ancestral
+// surnames in descending order.
+func (a *analysis) nameFrequencies() cousins.Frequencies {
+	nameFreqs := a.ancestries.FrequenciesOfNames(a.names)
+	sort.Stable(sort.Reverse(&nameFreqs))
+	return nameFreqs
+}
+
+// heatmapFrequencies returns the frequencies of countries and
+// US states in descending order. US and USA are substituted by
+// US state names.
+func (a *analysis) heatmapFrequencies() cousins.Frequencies {
+	// Create a list of heatmap locations.
+	locations := make(map[string]bool)
+	for country, _ := range a.countries {
+		locations[country] = true
+	}
+	for _, state := range usStates {
+		locations[state] = true
+	}
+	delete(locations, "US")
+	delete(locations, "USA")
+
+	// Calculate frequencies of cousins.
+	regionFreqs := a.ancestries.FrequenciesOf(locations)
+	sort.Stable(sort.Reverse(&regionFreqs))
+	return regionFreqs
+}
+
+// printFilters prints the filters that are applied by the options.
+func printFilters(opts *options) {
+	if opts.exclude != "" {
+		fmt.Printf("Cousins who's ancestral surnames or locations match %v are excluded from analysis.\r\n\r\n", opts.exclude)
+	}
+	if opts.cluster != "" {
+		fmt.Printf("Cluster analysis for %v.\r\n\r\n", opts.cluster)
+	}
+	switch {
+	case opts.after != 0 && opts.before != 0:
+		fmt.Printf("Only cousins with ancestors between %d and %d are analysed.\r\n\r\n", opts.after, opts.before)
+	case opts.after != 0:
+		fmt.Printf("Only cousins with ancestors after %d are analysed.\r\n\r\n", opts.after)
+	case opts.before != 0:
+		fmt.Printf("Only cousins with ancestors before %d are analysed.\r\n\r\n", opts.before)
+	}
+	if opts.notes != "" {
+		fmt.Printf("Only cousins whose notes contain %q are analysed.\r\n\r\n", opts.notes)
+	}
+	if opts.xmatch {
+		fmt.Print("Only cousins who share DNA on the X chromosome are analysed.\r\n\r\n")
+	}
+	if opts.yhg != "" {
+		fmt.Printf("Only cousins with Y-DNA haplogroup %v are analysed.\r\n\r\n", opts.yhg)
+	}
+	if opts.mthg != "" {
+		fmt.Printf("Only cousins with mtDNA haplogroup %v are analysed.\r\n\r\n", opts.mthg)
+	}
+}
+
+// frequenciesOfType returns the frequencies of countries, locations,
+// surnames or heatmap regions in descending order. The result is false
+// if typ is none of these.
+func (a *analysis) frequenciesOfType(typ string) (cousins.Frequencies, bool) {
+	switch typ {
+	case "countries":
+		return a.countryFrequencies(), true
+	case "locations":
+		return a.locationFrequencies(), true
+	case "surnames":
+		return a.nameFrequencies(), true
+	case "heatmap":
+		return a.heatmapFrequencies(), true
+	}
+	return nil, false
+}
+
+// printTreeComparison ranks the cousins by their overlap
+// with the family tree given by the -tree option.
+func (a *analysis) printTreeComparison(ownAncestry cousins.Ancestry) {
+	overlaps := a.ancestries.CompareWith(ownAncestry)
+	sort.Stable(sort.Reverse(&overlaps))
+	fmt.Print("\r\n--- Cousins ranked by overlap with family tree ---\r\n")
+	fmt.Print("Score:  Cousin:  Shared surnames, locations and surnames@locations:\r\n")
+	for _, overlap := range overlaps {
+		shared := append(append(append([]string{}, overlap.Names...), overlap.Locations...), overlap.Pairs...)
+		fmt.Printf("%.1f %v: %v\r\n", overlap.Score, overlap.Match, strings.Join(shared, ", "))
+	}
+}
+
+// printHaplogroups prints the frequencies of Y-DNA and mtDNA
+// haplogroups. If locations is true, the ancestral locations of the
+// cousins of each haplogroup are shown as well.
+func (a *analysis) printHaplogroups(min int, locations bool) {
+	yFreqs := a.yHaplogroupFrequencies()
+	mtFreqs := a.mtHaplogroupFrequencies()
+	fmt.Print("\r\n--- Y-DNA haplogroups ---\r\n")
+	fmt.Print("Number of cousins:  Haplogroup:\r\n")
+	printFrequencies(yFreqs, min)
+	fmt.Print("\r\n--- mtDNA haplogroups ---\r\n")
+	fmt.Print("Number of cousins:  Haplogroup:\r\n")
+	printFrequencies(mtFreqs, min)
+	if !locations {
+		return
+	}
+	fmt.Print("\r\n--- Locations per Y-DNA haplogroup ---\r\n")
+	fmt.Print("Haplogroup:  Locations (Number of cousins):\r\n")
+	printCrosstabRows(crosstabRows(yFreqs, a.ancestries.LocationsByYHaplogroup(), min))
+	fmt.Print("\r\n--- Locations per mtDNA haplogroup ---\r\n")
+	fmt.Print("Haplogroup:  Locations (Number of cousins):\r\n")
+	printCrosstabRows(crosstabRows(mtFreqs, a.ancestries.LocationsByMtHaplogroup(), min))
+}
+
+// printTags prints the frequencies of the tags in the notes. If
+// locations is true, the ancestral locations of the cousins with
+// each tag are shown as well.
+func (a *analysis) printTags(min int, locations bool) {
+	tagFreqs := a.tagFrequencies()
+	fmt.Print("\r\n--- Tags in notes ---\r\n")
+	fmt.Print("Number of cousins:  Tag:\r\n")
+	printFrequencies(tagFreqs, min)
+	if !locations {
+		return
+	}
+	fmt.Print("\r\n--- Locations per tag ---\r\n")
+	fmt.Print("Tag:  Locations (Number of cousins):\r\n")
+	printCrosstabRows(crosstabRows(tagFreqs, a.ancestries.LocationsByTag(), min))
+}
+
+// tagFrequencies returns the frequencies of the tags
+// in descending order.
+func (a *analysis) tagFrequencies() cousins.Frequencies {
+	freqs := a.ancestries.TagFrequencies()
+	sort.Stable(sort.Reverse(&freqs))
+	return freqs
+}
+
+// yHaplogroupFrequencies returns the frequencies of the Y-DNA
+// haplogroups in descending order.
+func (a *analysis) yHaplogroupFrequencies() cousins.Frequencies {
+	freqs := a.ancestries.YHaplogroupFrequencies()
+	sort.Stable(sort.Reverse(&freqs))
+	return freqs
+}
+
+// mtHaplogroupFrequencies returns the frequencies of the mtDNA
+// haplogroups in descending order.
+func (a *analysis) mtHaplogroupFrequencies() cousins.Frequencies {
+	freqs := a.ancestries.MtHaplogroupFrequencies()
+	sort.Stable(sort.Reverse(&freqs))
+	return freqs
+}
+
+// printFrequencies prints all frequencies that occur at least min times.
+func printFrequencies(freqs cousins.Frequencies, min int) {
+	for _, freq := range freqs {
+		if freq.NCousins >= min {
+			fmt.Printf("%v %v\r\n", freq.NCousins, freq.Name)
+		}
+	}
+}
+
+// printRates prints all frequencies that occur at least min times
+// together with the number of cousins per million testers. The
+// frequencies are ordered by this rate, followed by the frequencies
+// for which the number of testers is unknown.
+func printRates(freqs cousins.Frequencies, min int, population cousins.Population) {
+	sorted := atLeast(freqs, min)
+	rates := perMillion(sorted, population)
+	rate := func(freq cousins.Frequency) float64 {
+		if r, ok := rates[freq.Name]; ok {
+			return r
+		}
+		return -1
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return rate(sorted[i]) > rate(sorted[j]) })
+	for _, freq := range sorted {
+		if r := rate(freq); r >= 0 {
+			fmt.Printf("%v %.1f %v\r\n", freq.NCousins, r, freq.Name)
+		} else {
+			fmt.Printf("%v - %v\r\n", freq.NCousins, freq.Name)
+		}
+	}
+}
+
+// printDatedFrequencies prints all frequencies that occur at least
+// min times together with their typical years, if known.
+func printDatedFrequencies(freqs cousins.Frequencies, min int, years map[string]cousins.Years) {
+	for _, freq := range freqs {
+		if freq.NCousins < min {
+			continue
+		}
+		if period, ok := years[freq.Name]; ok {
+			fmt.Printf("%v %v (%v)\r\n", freq.NCousins, freq.Name, period)
+		} else {
+			fmt.Printf("%v %v\r\n", freq.NCousins, freq.Name)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var segmentsCommand = &command{
+	name:  "segments",
+	usage: "[options] <matches file> <chromosome browser file>",
+	short: "Analyses the ancestry of matches who share overlapping DNA segments.",
+	long: "Segments reads the shared segments exported by Family Finder's chromosome browser\r\n" +
+		"and groups segments that share a common region into clusters. Matches who share\r\n" +
+		"overlapping segments are potential triangulation groups. For each cluster the\r\n" +
+		"countries of the matches are shown, with -details also their locations and surnames.",
+	run: runSegments,
+}
+
+func runSegments(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	fs.BoolVar(&opts.details, "details", false, "Performs detailed analysis for locations and surnames.")
+	minCM := fs.Float64("mincm", 7, "Ignores segments shorter than <mincm> centimorgans, if the file contains centimorgans.")
+	minMatches := fs.Int("minmatches", 2, "Shows only clusters with at least <minmatches> matches.")
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	if len(files) != 2 {
+		return errors.New("segments needs a matches file and a chromosome browser file")
+	}
+	if *minMatches < 1 {
+		return fmt.Errorf("invalid value %d for -minmatches, must be at least 1", *minMatches)
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+
+	ancestries, err := opts.loadAncestries(files[0])
+	if err != nil {
+		return err
+	}
+	segments, err := cousins.ReadSegments(files[1], opts.encoding, *minCM)
+	if err != nil {
+		return fmt.Errorf("reading chromosome browser file %v", err)
+	}
+	printFilters(&opts)
+	a := newAnalysis(ancestries)
+	a.filter(&opts)
+
+	clusters := cousins.ClusterSegments(segments)
+	shown := 0
+	for _, cluster := range clusters {
+		matches := cluster.Matches()
+		if len(matches) < *minMatches {
+			continue
+		}
+		shown++
+		fmt.Printf("--- Chromosome %v: %d-%d, %d matches ---\r\n", cluster.Chromosome, cluster.Start, cluster.End, len(matches))
+		fmt.Printf("Matches: %v\r\n", strings.Join(matches, ", "))
+		ca := &analysis{
+			ancestries: a.ancestries.WithMatches(matches),
+			names:      a.names,
+			locations:  a.locations,
+			countries:  a.countries,
+		}
+		if len(ca.ancestries) == 0 {
+			fmt.Print("No ancestral information found.\r\n\r\n")
+			continue
+		}
+		fmt.Print("Number of cousins:  Ancestry from:\r\n")
+		printFrequencies(ca.countryFrequencies(), opts.min)
+		if opts.details {
+			fmt.Print("Number of cousins:  Location:\r\n")
+			printFrequencies(ca.locationFrequencies(), opts.min)
+			fmt.Print("Number of cousins:  Surname:\r\n")
+			printFrequencies(ca.nameFrequencies(), opts.min)
+		}
+		fmt.Print("\r\n")
+	}
+	if shown == 0 {
+		fmt.Print("No clusters of overlapping segments found.\r\n")
+	}
+	return nil
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"crypto/subtle"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var serveCommand = &command{
+	name:  "serve",
+	usage: "[options]",
+	short: "Starts a web server for analysing matches files in a browser.",
+	long: "Serve starts a web server on your computer. Open the shown address in a web browser\r\n" +
+		"to upload matches files or pick them from the directory given by -dir.\r\n" +
+		"The server works completely offline and keeps only the most recently loaded files.\r\n" +
+		"Other programs can use the JSON API below /kits and /compare,\r\n" +
+		"see the documentation for details. A server that is reachable from other\r\n" +
+		"computers needs a -token, which must be sent with every request, either as\r\n" +
+		"parameter token or in the header \"Authorization: Bearer <token>\".",
+	run: runServe,
+}
+
+// webFiles contains the templates and style sheets of the web pages.
+//
+//go:embed web
+var webFiles embed.FS
+
+// Limits protecting the server from running out of memory.
+const (
+	// maxUploadSize is the maximal size of a request body in bytes.
+	maxUploadSize = 32 << 20
+	// maxKits is the maximal number of kits kept in memory.
+	// If more kits are loaded, the oldest ones are removed.
+	maxKits = 20
+)
+
+// kit is a matches file loaded into the server.
+type kit struct {
+	ID         string
+	Name       string
+	ancestries cousins.Ancestries
+	// index is the Index of ancestries, which is used by all filters.
+	index *cousins.Index
+}
+
+// server serves the web pages.
+type server struct {
+	opts      *options
+	dir       string
+	templates *template.Template
+
+	// mutex protects kits and nextID.
+	mutex  sync.Mutex
+	kits   map[string]*kit
+	nextID int
+}
+
+func runServe(cmd *command, args []string) error {
+	opts := &options{}
+	fs := cmd.flagSet()
+	opts.registerInput(fs)
+	opts.registerTesters(fs)
+	addr := fs.String("addr", "127.0.0.1:8080", "Network address of the web server.")
+	token := fs.String("token", "", "Secret that clients must send with every request. Required if -addr is not a loopback address.")
+	dir := fs.String("dir", ".", "Directory containing matches files.")
+	fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+	if *token == "" && !isLoopback(*addr) {
+		return errors.New("a server reachable from other computers needs a -token")
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+	templates, err := template.New("").Funcs(templateFuncs).ParseFS(webFiles, "web/*.html")
+	if err != nil {
+		return err
+	}
+	srv := &server{opts: opts, dir: *dir, templates: templates, kits: make(map[string]*kit)}
+	srv.registerUI(http.DefaultServeMux)
+	srv.registerAPI(http.DefaultServeMux)
+	address := "http://" + browserAddress(*addr)
+	handler := limitBody(http.DefaultServeMux)
+	if *token != "" {
+		address += "/?token=" + *token
+		handler = requireToken(*token, handler)
+	}
+	fmt.Printf("Open %s in your web browser.\r\n", address)
+	return http.ListenAndServe(*addr, handler)
+}
+
+// isLoopback reports whether the network address addr
+// can only be reached from the own computer.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// tokenCookie is the name of the cookie that stores the token
+// in web browsers, so that it must only be given once.
+const tokenCookie = "familyties-token"
+
+// requireToken rejects requests that do not contain the token as
+// parameter, cookie or in the Authorization header.
+func requireToken(token string, next http.Handler) http.Handler {
+	valid := func(s string) bool {
+		return subtle.ConstantTimeCompare([]byte(s), []byte(token)) == 1
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if valid(r.URL.Query().Get("token")) {
+			http.SetCookie(w, &http.Cookie{Name: tokenCookie, Value: token, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+			next.ServeHTTP(w, r)
+			return
+		}
+		if cookie, err := r.Cookie(tokenCookie); err == nil && valid(cookie.Value) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if valid(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		httpError(w, r, "missing or wrong token", http.StatusUnauthorized)
+	})
+}
+
+// httpError replies to requests of the JSON API with an error in
+// JSON format and to all other requests with a plain text error.
+func httpError(w http.ResponseWriter, r *http.Request, message string, status int) {
+	if isAPIPath(r.URL.Path) {
+		writeJSONError(w, status, message)
+	} else {
+		http.Error(w, message, status)
+	}
+}
+
+// limitBody rejects requests whose body is larger than maxUploadSize,
+// so that uploads can not exhaust the memory of the server.
+func limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxUploadSize {
+			httpError(w, r, fmt.Sprintf("file too large, the maximum is %d MB", maxUploadSize>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+		// Bodies without length are cut off.
+		r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// frequencyTable contains the data of the frequencies template.
+type frequencyTable struct {
+	Kit     *kit
+	Filters filters
+	Caption string
+	Freqs   cousins.Frequencies
+}
+
+// templateFuncs are the functions available in templates.
+var templateFuncs = template.FuncMap{
+	"table": func(k *kit, f filters, caption string, freqs cousins.Frequencies) frequencyTable {
+		return frequencyTable{k, f, caption, freqs}
+	},
+}
+
+// browserAddress returns the address to be typed into a browser
+// for the network address addr.
+func browserAddress(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
+
+// registerUI registers the handlers of the web pages.
+func (s *server) registerUI(mux *http.ServeMux) {
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/upload", s.handleUpload)
+	mux.HandleFunc("/open", s.handleOpen)
+	mux.HandleFunc("/kit", s.handleKit)
+	mux.HandleFunc("/cousins", s.handleCousins)
+	mux.Handle("/web/", http.FileServer(http.FS(webFiles)))
+}
+
+// addKit stores Ancestries in the server and returns the new kit.
+// If there are more than maxKits kits, the oldest ones are removed.
+func (s *server) addKit(name string, ancestries cousins.Ancestries) *kit {
+	index := cousins.NewIndex(ancestries)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextID++
+	k := &kit{ID: strconv.Itoa(s.nextID), Name: name, ancestries: ancestries, index: index}
+	s.kits[k.ID] = k
+	for len(s.kits) > maxKits {
+		oldest := s.nextID
+		for id, _ := range s.kits {
+			if n, _ := strconv.Atoi(id); n < oldest {
+				oldest = n
+			}
+		}
+		delete(s.kits, strconv.Itoa(oldest))
+	}
+	return k
+}
+
+// kit returns the kit with the given ID or nil.
+func (s *server) kit(id string) *kit {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.kits[id]
+}
+
+// kitList returns all kits ordered by ID.
+func (s *server) kitList() []*kit {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := make([]*kit, 0, len(s.kits))
+	for _, k := range s.kits {
+		result = append(result, k)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, _ := strconv.Atoi(result[i].ID)
+		b, _ := strconv.Atoi(result[j].ID)
+		return a < b
+	})
+	return result
+}
+
+// csvFiles returns the names of the CSV files in the server's directory.
+func (s *server) csvFiles() []string {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil
+	}
+	var result []string
+	for _, info := range infos {
+		if !info.IsDir() && strings.EqualFold(filepath.Ext(info.Name()), ".csv") {
+			result = append(result, info.Name())
+		}
+	}
+	return result
+}
+
+func (s *server) render(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	s.render(w, "index.html", struct {
+		Kits  []*kit
+		Files []string
+		Dir   string
+	}{s.kitList(), s.csvFiles(), s.dir})
+}
+
+func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("no file uploaded or file larger than %d MB", maxUploadSize>>20), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ancestries, err := s.opts.parseAncestries(data)
+	if err != nil {
+		http.Error(w, "Error reading matches file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	k := s.addKit(header.Filename, ancestries)
+	http.Redirect(w, r, "/kit?id="+k.ID, http.StatusSeeOther)
+}
+
+func (s *server) handleOpen(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// Only files from the listing of the directory may be opened.
+	name := r.FormValue("name")
+	found := false
+	for _, filename := range s.csvFiles() {
+		if filename == name {
+			found = true
+		}
+	}
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	ancestries, err := s.opts.loadAncestries(filepath.Join(s.dir, name))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	k := s.addKit(name, ancestries)
+	http.Redirect(w, r, "/kit?id="+k.ID, http.StatusSeeOther)
+}
+
+// filters are the filter settings of a web page.
+type filters struct {
+	Cluster string
+	Exclude string
+	Min     int
+}
+
+// filtersOf reads the filter settings from a request.
+func filtersOf(r *http.Request) filters {
+	f := filters{
+		Cluster: strings.TrimSpace(r.FormValue("cluster")),
+		Exclude: strings.TrimSpace(r.FormValue("exclude")),
+		Min:     1,
+	}
+	if min, err := strconv.Atoi(r.FormValue("min")); err == nil && min > 0 {
+		f.Min = min
+	}
+	return f
+}
+
+// analysis creates a filtered analysis of the kit.
+func (f filters) analysis(k *kit) *analysis {
+	a := newIndexedAnalysis(k.index)
+	a.filter(&options{cluster: f.Cluster, exclude: f.Exclude})
+	return a
+}
+
+// mapPoint is a location on the map of ancestral locations.
+type mapPoint struct {
+	Name     string
+	NCousins int
+	X, Y, R  float64
+}
+
+// Size of the map in pixels.
+const (
+	mapWidth  = 720
+	mapHeight = 360
+)
+
+// mapPoints places the frequencies of countries and US states on
+// a map using an equirectangular projection. The area of each circle
+// is proportional to the number of cousins.
+func mapPoints(freqs cousins.Frequencies) []mapPoint {
+	max := 0
+	for _, freq := range freqs {
+		if freq.NCousins > max {
+			max = freq.NCousins
+		}
+	}
+	var result []mapPoint
+	for _, freq := range freqs {
+		coord, ok := coordinates[freq.Name]
+		if !ok || freq.NCousins == 0 {
+			continue
+		}
+		result = append(result, mapPoint{
+			Name:     freq.Name,
+			NCousins: freq.NCousins,
+			X:        (coord[1] + 180) * mapWidth / 360,
+			Y:        (90 - coord[0]) * mapHeight / 180,
+			R:        2 + 18*math.Sqrt(float64(freq.NCousins)/float64(max)),
+		})
+	}
+	return result
+}
+
+func (s *server) handleKit(w http.ResponseWriter, r *http.Request) {
+	k := s.kit(r.FormValue("id"))
+	if k == nil {
+		http.NotFound(w, r)
+		return
+	}
+	f := filtersOf(r)
+	a := f.analysis(k)
+	s.render(w, "kit.html", struct {
+		Kit     *kit
+		Filters filters
+		Report  Report
+		Map     []mapPoint
+		Width   int
+		Height  int
+	}{k, f, newReport(a, f.Min, s.opts.population), mapPoints(a.heatmapFrequencies()), mapWidth, mapHeight})
+}
+
+func (s *server) handleCousins(w http.ResponseWriter, r *http.Request) {
+	k := s.kit(r.FormValue("id"))
+	if k == nil {
+		http.NotFound(w, r)
+		return
+	}
+	f := filtersOf(r)
+	term := strings.TrimSpace(r.FormValue("term"))
+	a := f.analysis(k)
+	ancestries := a.ancestries
+	if term != "" {
+		index, positions := a.indexed()
+		ancestries = index.Select(index.IncludeAt(positions, term))
+	}
+	s.render(w, "cousins.html", struct {
+		Kit        *kit
+		Filters    filters
+		Term       string
+		Ancestries cousins.Ancestries
+	}{k, f, term, ancestries})
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var shellCommand = &command{
+	name:  "shell",
+	usage: "[options] <matches file>...",
+	short: "Explores matches files interactively.",
+	long: "Shell loads the matches files once and reads commands from the keyboard.\r\n" +
+		"Several input files are united. Type \"help\" in the shell for a list of commands.",
+	run: runShell,
+}
+
+// shellHelp describes the commands of the shell.
+const shellHelp = `Commands:
+  cluster <terms>             Keeps only cousins matching one of the comma separated terms.
+  exclude <terms>             Removes cousins matching one of the comma separated terms.
+  top countries [n]           Shows the n most frequent predefined countries.
+  top locations [n]           Shows the n most frequent ancestral locations.
+  top surnames [n]            Shows the n most frequent ancestral surnames.
+  show cousins [in <term>]    Lists the cousins and their ancestral information.
+  export csv|json <file>      Writes the current frequencies to a file.
+  export heatmap <file>       Writes countries and US states for a heat map.
+  status                      Shows the applied filters.
+  undo                        Reverts the last cluster or exclude command.
+  reset                       Reverts all cluster and exclude commands.
+  help                        Shows this help.
+  quit                        Leaves the shell.
+`
+
+// shellState is the result of a filter operation in the shell.
+type shellState struct {
+	analysis *analysis
+	// filter describes the operation that created the state.
+	filter string
+}
+
+// shell is an interactive session for exploring Ancestries.
+type shell struct {
+	// history contains all states, the current state is the last one.
+	history []shellState
+	out     io.Writer
+	// files are the input files, which must not be overwritten.
+	files []string
+	// min is the minimal frequency of shown and exported entries.
+	min int
+	// population is the number of testers per country, may be nil.
+	population cousins.Population
+}
+
+func runShell(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	opts.registerTesters(fs)
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	if len(files) == 0 {
+		return errors.New("no input filename specified")
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+	var ancestries cousins.Ancestries
+	if len(files) == 1 {
+		var err error
+		ancestries, err = opts.loadAncestries(files[0])
+		if err != nil {
+			return err
+		}
+	} else {
+		list, err := opts.loadList(files)
+		if err != nil {
+			return err
+		}
+		ancestries = list.Unite()
+	}
+
+	// The -cluster and -exclude options create the initial state.
+	sh := &shell{out: os.Stdout, files: files, min: opts.min, population: opts.population}
+	a := newAnalysis(ancestries)
+	a.filter(&opts)
+	sh.history = []shellState{{analysis: a, filter: "load " + strings.Join(files, ",")}}
+	fmt.Fprintf(sh.out, "Loaded %d cousins. Type \"help\" for a list of commands.\r\n", len(a.ancestries))
+	return sh.run(os.Stdin)
+}
+
+// run reads and executes commands until the input ends
+// or the user quits.
+func (sh *shell) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(sh.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprint(sh.out, "\r\n")
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "quit" || line == "exit" {
+			return nil
+		}
+		if line == "" {
+			continue
+		}
+		if err := sh.execute(line); err != nil {
+			fmt.Fprintf(sh.out, "Error, %v.\r\n", err)
+		}
+	}
+}
+
+// current returns the analysis of the current state.
+func (sh *shell) current() *analysis {
+	return sh.history[len(sh.history)-1].analysis
+}
+
+// push makes a copy of the current analysis with the Ancestries at
+// the given positions of its Index the current state. All states
+// share the Index that is built for the loaded Ancestries.
+func (sh *shell) push(positions []int, filter string) {
+	a := *sh.current()
+	a.selectPositions(positions)
+	sh.history = append(sh.history, shellState{analysis: &a, filter: filter})
+	fmt.Fprintf(sh.out, "%d cousins left.\r\n", len(positions))
+}
+
+// execute executes a single command line.
+func (sh *shell) execute(line string) error {
+	fields := strings.Fields(line)
+	command, args := strings.ToLower(fields[0]), fields[1:]
+	rest := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+	switch command {
+	case "help":
+		fmt.Fprint(sh.out, strings.Replace(shellHelp, "\n", "\r\n", -1))
+	case "cluster":
+		if rest == "" {
+			return errors.New("cluster needs surnames or locations")
+		}
+		index, positions := sh.current().indexed()
+		sh.push(index.IncludeAt(positions, splitTerms(rest)...), line)
+	case "exclude":
+		if rest == "" {
+			return errors.New("exclude needs surnames or locations")
+		}
+		index, positions := sh.current().indexed()
+		sh.push(index.ExcludeAt(positions, splitTerms(rest)...), line)
+	case "undo":
+		if len(sh.history) == 1 {
+			return errors.New("nothing to undo")
+		}
+		sh.history = sh.history[:len(sh.history)-1]
+		fmt.Fprintf(sh.out, "%d cousins left.\r\n", len(sh.current().ancestries))
+	case "reset":
+		sh.history = sh.history[:1]
+		fmt.Fprintf(sh.out, "%d cousins left.\r\n", len(sh.current().ancestries))
+	case "status":
+		for _, state := range sh.history {
+			fmt.Fprintf(sh.out, "%d cousins after %s\r\n", len(state.analysis.ancestries), state.filter)
+		}
+	case "top":
+		return sh.top(args)
+	case "show":
+		return sh.show(args)
+	case "export":
+		return sh.export(args)
+	default:
+		return fmt.Errorf("unknown command %s, type \"help\" for a list of commands", command)
+	}
+	return nil
+}
+
+// top prints the most frequent countries, locations or surnames.
+func (sh *shell) top(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("usage: top countries|locations|surnames [n]")
+	}
+	n := 10
+	if len(args) == 2 {
+		var err error
+		n, err = strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number %s", args[1])
+		}
+	}
+	a := sh.current()
+	var freqs cousins.Frequencies
+	switch strings.ToLower(args[0]) {
+	case "countries":
+		freqs = a.countryFrequencies()
+	case "locations":
+		freqs = a.locationFrequencies()
+	case "surnames", "names":
+		freqs = a.nameFrequencies()
+	default:
+		return errors.New("usage: top countries|locations|surnames [n]")
+	}
+	freqs = atLeast(freqs, sh.min)
+	if len(freqs) > n {
+		freqs = freqs[:n]
+	}
+	fmt.Fprint(sh.out, "Number of cousins:  Name:\r\n")
+	for _, freq := range freqs {
+		fmt.Fprintf(sh.out, "%v %v\r\n", freq.NCousins, freq.Name)
+	}
+	return nil
+}
+
+// show lists the cousins, optionally restricted to those
+// matching a term: show cousins in bavaria.
+func (sh *shell) show(args []string) error {
+	if len(args) == 0 || strings.ToLower(args[0]) != "cousins" {
+		return errors.New("usage: show cousins [in <term>]")
+	}
+	ancestries := sh.current().ancestries
+	if len(args) > 1 {
+		if strings.ToLower(args[1]) != "in" || len(args) < 3 {
+			return errors.New("usage: show cousins [in <term>]")
+		}
+		index, positions := sh.current().indexed()
+		ancestries = index.Select(index.IncludeAt(positions, splitTerms(strings.Join(args[2:], " "))...))
+	}
+	for _, ancestry := range ancestries {
+		fmt.Fprintf(sh.out, "%v: %v\r\n", ancestry.Match, ancestry.Line())
+	}
+	fmt.Fprintf(sh.out, "%d cousins.\r\n", len(ancestries))
+	return nil
+}
+
+// export writes the frequencies of the current state to a file.
+func (sh *shell) export(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: export csv|json|heatmap <file>")
+	}
+	format, filename := strings.ToLower(args[0]), args[1]
+	if err := checkOutputFile(filename, sh.files); err != nil {
+		return err
+	}
+	a := sh.current()
+	if format == "heatmap" {
+		regionFreqs := a.heatmapFrequencies()
+		return regionFreqs.WriteCSV(filename)
+	}
+	if format != "csv" && format != "json" {
+		return errors.New("usage: export csv|json|heatmap <file>")
+	}
+	outfile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	report := newReport(a, sh.min, sh.population)
+	if format == "json" {
+		err = report.WriteJSON(outfile)
+	} else {
+		err = report.WriteCSV(outfile)
+	}
+	if cerr := outfile.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		fmt.Fprintf(sh.out, "Written to %s.\r\n", filename)
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"math"
+)
+
+// logChoose returns the natural logarithm of the binomial
+// coefficient n over k.
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// fisherExact returns the two-sided p-value of Fisher's exact test
+// for the 2x2 contingency table
+//
+//	a b
+//	c d
+//
+// It is the probability of a table at least as extreme as the given
+// one if both rows have the same proportions.
+func fisherExact(a, b, c, d int) float64 {
+	row1, col1, n := a+b, a+c, a+b+c+d
+	prob := func(k int) float64 {
+		return math.Exp(logChoose(row1, k) + logChoose(n-row1, col1-k) - logChoose(n, col1))
+	}
+	observed := prob(a)
+	min := col1 - (n - row1)
+	if min < 0 {
+		min = 0
+	}
+	max := row1
+	if col1 < max {
+		max = col1
+	}
+	p := 0.0
+	for k := min; k <= max; k++ {
+		// Allow for rounding errors when comparing probabilities.
+		if pk := prob(k); pk <= observed*(1+1e-7) {
+			p += pk
+		}
+	}
+	if p > 1 {
+		p = 1
+	}
+	return p
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFisherExact(t *testing.T) {
+	tests := []struct {
+		a, b, c, d int
+		want       float64
+	}{
+		// Fisher's tea tasting experiment.
+		{3, 1, 1, 3, 0.4857142857},
+		{4, 0, 0, 4, 0.0285714286},
+		// Equal proportions.
+		{2, 2, 2, 2, 1},
+		{1, 9, 11, 3, 0.0027594562},
+	}
+	for _, test := range tests {
+		got := fisherExact(test.a, test.b, test.c, test.d)
+		if math.Abs(got-test.want) > 1e-8 {
+			t.Errorf("fisherExact(%d, %d, %d, %d) = %.10f, want %.10f", test.a, test.b, test.c, test.d, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var timelineCommand = &command{
+	name:  "timeline",
+	usage: "[options] <matches file>",
+	short: "Shows how the ancestry of new matches changes over time.",
+	long: "Timeline groups the matches by the month or year of their match date and shows\r\n" +
+		"for each period which percentage of the new cousins has ancestry from the\r\n" +
+		"most frequent countries, locations or surnames. The results can be written\r\n" +
+		"to a CSV file and drawn as an SVG chart.",
+	run: runTimeline,
+}
+
+// timeline contains the frequencies of the most frequent items
+// for each period of a timeline.
+type timeline struct {
+	byYear  bool
+	periods []cousins.Period
+	// items are the names of the evaluated countries, locations
+	// or surnames, the most frequent first.
+	items []string
+	// counts contains for each period the number of cousins
+	// for each item.
+	counts [][]int
+}
+
+func runTimeline(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	by := fs.String("by", "year", "Groups matches by year or month of the match date.")
+	typ := fs.String("type", "countries", "Evaluates countries, locations or surnames.")
+	top := fs.Int("top", 5, "Number of most frequent countries, locations or surnames to show.")
+	csvout := fs.String("csvout", "", "Writes the timeline to a file in CSV format.")
+	svgout := fs.String("svgout", "", "Draws the timeline as a chart in an SVG file.")
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	switch {
+	case len(files) == 0:
+		return errors.New("no input filename specified")
+	case len(files) > 1:
+		return errors.New("timeline accepts only one input file")
+	}
+	if *by != "year" && *by != "month" {
+		return fmt.Errorf("invalid value %q for -by, must be year or month", *by)
+	}
+	if *typ != "countries" && *typ != "locations" && *typ != "surnames" {
+		return fmt.Errorf("invalid value %q for -type, must be countries, locations or surnames", *typ)
+	}
+	if *top < 1 {
+		return fmt.Errorf("invalid value %d for -top, must be at least 1", *top)
+	}
+	for _, output := range []string{*csvout, *svgout} {
+		if err := checkOutputFile(output, files); err != nil {
+			return err
+		}
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+
+	ancestries, err := opts.loadAncestries(files[0])
+	if err != nil {
+		return err
+	}
+	printFilters(&opts)
+	a := newAnalysis(ancestries)
+	a.filter(&opts)
+	t, undated := newTimeline(a, *typ, *by == "year", *top, opts.min)
+	if undated > 0 {
+		fmt.Printf("%d cousins without match date are not shown.\r\n\r\n", undated)
+	}
+	if len(t.periods) == 0 {
+		fmt.Print("No data found.\r\n")
+		return nil
+	}
+	t.print()
+
+	if *csvout != "" {
+		if err := writeFile(*csvout, t.writeCSV); err != nil {
+			fmt.Printf("Error writing timeline to file in CSV format, %v.\r\n", err)
+		}
+	}
+	if *svgout != "" {
+		if err := writeFile(*svgout, t.writeSVG); err != nil {
+			fmt.Printf("Error writing timeline chart, %v.\r\n", err)
+		}
+	}
+	return nil
+}
+
+// newTimeline creates a timeline for the top most frequent items
+// of the given type that occur at least min times. It also returns
+// the number of cousins without match date.
+func newTimeline(a *analysis, typ string, byYear bool, top, min int) (*timeline, int) {
+	t := &timeline{byYear: byYear}
+	var undated cousins.Ancestries
+	t.periods, undated = a.ancestries.Timeline(byYear)
+
+	freqs, _ := a.frequenciesOfType(typ)
+	items := make(map[string]bool)
+	for _, freq := range freqs {
+		if len(t.items) == top || freq.NCousins < min {
+			break
+		}
+		t.items = append(t.items, freq.Name)
+		items[freq.Name] = true
+	}
+
+	// Count the cousins of each period for the selected items only.
+	for _, period := range t.periods {
+		p := &analysis{ancestries: period.Ancestries, names: items, locations: items, countries: items}
+		periodFreqs, _ := p.frequenciesOfType(typ)
+		byName := make(map[string]int, len(periodFreqs))
+		for _, freq := range periodFreqs {
+			byName[freq.Name] = freq.NCousins
+		}
+		counts := make([]int, len(t.items))
+		for i, item := range t.items {
+			counts[i] = byName[item]
+		}
+		t.counts = append(t.counts, counts)
+	}
+	return t, len(undated)
+}
+
+// percentage returns the share of cousins of period i
+// that have ancestry from item j.
+func (t *timeline) percentage(i, j int) float64 {
+	n := len(t.periods[i].Ancestries)
+	if n == 0 {
+		return 0
+	}
+	return 100 * float64(t.counts[i][j]) / float64(n)
+}
+
+// print prints the timeline as a table and a bar chart
+// of the number of new cousins.
+func (t *timeline) print() {
+	fmt.Print("--- Percentage of new cousins per period ---\r\n")
+	// Each column is wide enough for the item and "100%".
+	widths := make([]int, len(t.items))
+	fmt.Printf("%-8s %7s", "Period", "Cousins")
+	for j, item := range t.items {
+		widths[j] = len([]rune(item))
+		if widths[j] < 4 {
+			widths[j] = 4
+		}
+		fmt.Printf("  %*s", widths[j], item)
+	}
+	fmt.Print("\r\n")
+	for i, period := range t.periods {
+		fmt.Printf("%-8s %7d", period.Label(t.byYear), len(period.Ancestries))
+		for j := range t.items {
+			fmt.Printf("  %*.0f%%", widths[j]-1, t.percentage(i, j))
+		}
+		fmt.Print("\r\n")
+	}
+
+	max := 0
+	for _, period := range t.periods {
+		if len(period.Ancestries) > max {
+			max = len(period.Ancestries)
+		}
+	}
+	fmt.Print("\r\n--- Number of new cousins per period ---\r\n")
+	for _, period := range t.periods {
+		n := len(period.Ancestries)
+		fmt.Printf("%-8s %s %d\r\n", period.Label(t.byYear), strings.Repeat("#", (n*50+max-1)/max), n)
+	}
+}
+
+// writeCSV writes the number of cousins for each period and item.
+func (t *timeline) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+	writer.Write(append([]string{"Period", "Cousins"}, t.items...))
+	for i, period := range t.periods {
+		record := []string{period.Label(t.byYear), strconv.Itoa(len(period.Ancestries))}
+		for _, count := range t.counts[i] {
+			record = append(record, strconv.Itoa(count))
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// chartColors are the colors of the lines in SVG charts.
+var chartColors = []string{"#1f77b4", "#d62728", "#2ca02c", "#ff7f0e", "#9467bd", "#8c564b", "#e377c2", "#17becf"}
+
+// writeSVG draws the timeline as a chart. Bars show the number of new
+// cousins, lines the percentage of cousins with ancestry from each item.
+func (t *timeline) writeSVG(w io.Writer) error {
+	const (
+		width, height = 800, 400
+		left, right   = 50, 160
+		top, bottom   = 20, 40
+		plotW, plotH  = width - left - right, height - top - bottom
+	)
+	max := 1
+	for _, period := range t.periods {
+		if len(period.Ancestries) > max {
+			max = len(period.Ancestries)
+		}
+	}
+	step := float64(plotW) / float64(len(t.periods))
+	x := func(i int) float64 { return left + step*(float64(i)+0.5) }
+	y := func(percent float64) float64 { return top + plotH*(1-percent/100) }
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`+"\n", width, height)
+	fmt.Fprintf(w, `<rect width="%d" height="%d" fill="white"/>`+"\n", width, height)
+
+	// Bars for the number of new cousins.
+	for i, period := range t.periods {
+		h := plotH * float64(len(period.Ancestries)) / float64(max)
+		fmt.Fprintf(w, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#e0e0e0"><title>%s: %d cousins</title></rect>`+"\n",
+			x(i)-step*0.4, top+plotH-h, step*0.8, h, period.Label(t.byYear), len(period.Ancestries))
+	}
+
+	// Axes with percentages on the left side.
+	fmt.Fprintf(w, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`+"\n", left, top, left, top+plotH)
+	fmt.Fprintf(w, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`+"\n", left, top+plotH, left+plotW, top+plotH)
+	for percent := 0; percent <= 100; percent += 25 {
+		fmt.Fprintf(w, `<text x="%d" y="%.1f" text-anchor="end">%d%%</text>`+"\n", left-5, y(float64(percent))+4, percent)
+	}
+	labelEvery := (len(t.periods) + 11) / 12
+	for i, period := range t.periods {
+		if i%labelEvery == 0 {
+			fmt.Fprintf(w, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n", x(i), top+plotH+15, period.Label(t.byYear))
+		}
+	}
+
+	// One line and a legend entry for each item.
+	for j, item := range t.items {
+		color := chartColors[j%len(chartColors)]
+		var points []string
+		for i := range t.periods {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(t.percentage(i, j))))
+		}
+		fmt.Fprintf(w, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", strings.Join(points, " "), color)
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/>`+"\n", left+plotW+15, top+j*18, color)
+		fmt.Fprintf(w, `<text x="%d" y="%d">%s</text>`+"\n", left+plotW+32, top+j*18+10, html.EscapeString(item))
+	}
+	_, err := fmt.Fprint(w, "</svg>\n")
+	return err
+}
+
+// writeFile creates a file and writes its content using write.
+func writeFile(filename string, write func(w io.Writer) error) error {
+	outfile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = write(outfile)
+	if cerr := outfile.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var triangulateCommand = &command{
+	name:  "triangulate",
+	usage: "[options] <matches file> <chromosome browser file> <ICW file>",
+	short: "Finds triangulation groups and their common ancestry.",
+	long: "Triangulate combines the shared segments of the chromosome browser with a list\r\n" +
+		"of matches who are in common with each other (ICW). A triangulation group\r\n" +
+		"consists of at least three matches who share an overlapping segment and who\r\n" +
+		"all match each other. For each group the surnames and locations are shown\r\n" +
+		"that are common to all matches of the group who provide ancestral information.\r\n" +
+		"The ICW file contains one pair of matches per row, for example in the\r\n" +
+		"columns \"Match Name\" and \"ICW Name\".",
+	run: runTriangulate,
+}
+
+func runTriangulate(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	fs.BoolVar(&opts.details, "details", false, "Shows the segments and ancestral lines of the matches of each group.")
+	minCM := fs.Float64("mincm", 7, "Ignores segments shorter than <mincm> centimorgans, if the file contains centimorgans.")
+	minSize := fs.Int("minsize", 3, "Shows only groups with at least <minsize> matches.")
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	if len(files) != 3 {
+		return errors.New("triangulate needs a matches file, a chromosome browser file and an ICW file")
+	}
+	if *minSize < 3 {
+		return fmt.Errorf("invalid value %d for -minsize, must be at least 3", *minSize)
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+
+	ancestries, err := opts.loadAncestries(files[0])
+	if err != nil {
+		return err
+	}
+	segments, err := cousins.ReadSegments(files[1], opts.encoding, *minCM)
+	if err != nil {
+		return fmt.Errorf("reading chromosome browser file %v", err)
+	}
+	shared, err := cousins.ReadSharedMatches(files[2], opts.encoding)
+	if err != nil {
+		return fmt.Errorf("reading ICW file %v", err)
+	}
+	printFilters(&opts)
+	a := newAnalysis(ancestries)
+	a.filter(&opts)
+
+	groups := cousins.Triangulate(cousins.ClusterSegments(segments), shared, *minSize)
+	if len(groups) == 0 {
+		fmt.Print("No triangulation groups found.\r\n")
+		return nil
+	}
+	for i, group := range groups {
+		matches := group.Matches()
+		fmt.Printf("--- Group %d, chromosome %v: %d-%d, %d matches ---\r\n", i+1, group.Chromosome, group.Start, group.End, len(matches))
+		fmt.Printf("Matches: %v\r\n", strings.Join(matches, ", "))
+		if opts.details {
+			for _, segment := range group.Segments {
+				fmt.Printf("  %v: %d-%d, %.1f cM\r\n", segment.Match, segment.Start, segment.End, segment.CM)
+			}
+		}
+		printGroupCommons(a.ancestries, &group, opts.details)
+		fmt.Print("\r\n")
+	}
+	return nil
+}
+
+// printGroupCommons prints the surnames and locations that are common
+// to all matches of a triangulation group who provide ancestral information.
+func printGroupCommons(ancestries cousins.Ancestries, group *cousins.TriangulationGroup, details bool) {
+	informative, names, locations := group.CommonAncestry(&ancestries)
+	fmt.Printf("Matches with ancestral information: %d\r\n", len(informative))
+	if details {
+		for _, ancestry := range informative {
+			fmt.Printf("  %v: %v\r\n", ancestry.Match, ancestry.Line())
+		}
+	}
+	if len(informative) < 2 {
+		return
+	}
+	fmt.Printf("Common surnames: %v\r\n", joinSet(names))
+	fmt.Printf("Common locations: %v\r\n", joinSet(locations))
+}
+
+// joinSet returns the sorted keys of set separated by commas
+// or "none" if the set is empty.
+func joinSet(set map[string]bool) string {
+	if len(set) == 0 {
+		return "none"
+	}
+	keys := make([]string, 0, len(set))
+	for key, _ := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
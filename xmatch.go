@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+var xmatchCommand = &command{
+	name:  "xmatch",
+	usage: "[options] <matches file>",
+	short: "Compares the ancestry of X-matches with the other cousins.",
+	long: "Xmatch compares the countries of cousins who also share DNA on the X chromosome\r\n" +
+		"with those of all other cousins. Enrichment is the ratio of both percentages,\r\n" +
+		"values above 1 mean that a country is more common among X-matches, values below 1\r\n" +
+		"that it is less common. The p-value of Fisher's exact test is the probability\r\n" +
+		"of such a difference by chance.\r\n" +
+		"With -details locations and surnames are compared as well.",
+	run: runXMatch,
+}
+
+// enrichment compares the frequency of a country, location or
+// surname among X-matches with the frequency among other cousins.
+type enrichment struct {
+	name     string
+	xmatches int
+	others   int
+	ratio    float64
+	p        float64
+}
+
+func runXMatch(cmd *command, args []string) error {
+	var opts options
+	fs := cmd.flagSet()
+	opts.register(fs)
+	fs.BoolVar(&opts.details, "details", false, "Compares locations and surnames as well.")
+	fs.Parse(args)
+
+	files := inputFiles(fs.Args())
+	switch {
+	case len(files) == 0:
+		return errors.New("no input filename specified")
+	case len(files) > 1:
+		return errors.New("xmatch accepts only one input file")
+	case opts.xmatch:
+		// All cousins are needed for the comparison.
+		return errors.New("xmatch compares X-matches with all other cousins and can not be used with -xmatch")
+	}
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+
+	ancestries, err := opts.loadAncestries(files[0])
+	if err != nil {
+		return err
+	}
+	printFilters(&opts)
+	a := newAnalysis(ancestries)
+	a.filter(&opts)
+	xmatches, others := a.ancestries.XMatches()
+	fmt.Printf("X-matches: %d of %d cousins.\r\n", len(xmatches), len(a.ancestries))
+	if len(xmatches) == 0 || len(others) == 0 {
+		fmt.Print("No comparison possible.\r\n")
+		return nil
+	}
+	xa := &analysis{ancestries: xmatches, names: a.names, locations: a.locations, countries: a.countries}
+	oa := &analysis{ancestries: others, names: a.names, locations: a.locations, countries: a.countries}
+
+	fmt.Print("\r\n--- Countries of X-matches compared with other cousins ---\r\n")
+	printEnrichments(compareFrequencies(xa.countryFrequencies(), oa.countryFrequencies(), len(xmatches), len(others)), len(xmatches), len(others), opts.min)
+	if !opts.details {
+		return nil
+	}
+	fmt.Print("\r\n--- Locations of X-matches compared with other cousins ---\r\n")
+	printEnrichments(compareFrequencies(xa.locationFrequencies(), oa.locationFrequencies(), len(xmatches), len(others)), len(xmatches), len(others), opts.min)
+	fmt.Print("\r\n--- Surnames of X-matches compared with other cousins ---\r\n")
+	printEnrichments(compareFrequencies(xa.nameFrequencies(), oa.nameFrequencies(), len(xmatches), len(others)), len(xmatches), len(others), opts.min)
+	return nil
+}
+
+// compareFrequencies calculates the enrichment of each frequency among
+// nx X-matches compared with no other cousins. Names that occur only
+// among the other cousins are included, so that depletion is reported
+// as well. The result is ordered by p-value, the most significant
+// differences first.
+func compareFrequencies(xfreqs, ofreqs cousins.Frequencies, nx, no int) []enrichment {
+	xcounts := make(map[string]int, len(xfreqs))
+	ocounts := make(map[string]int, len(ofreqs))
+	var names []string
+	for _, freq := range xfreqs {
+		if _, ok := xcounts[freq.Name]; !ok {
+			names = append(names, freq.Name)
+		}
+		xcounts[freq.Name] = freq.NCousins
+	}
+	for _, freq := range ofreqs {
+		if _, ok := xcounts[freq.Name]; !ok {
+			if _, ok := ocounts[freq.Name]; !ok {
+				names = append(names, freq.Name)
+			}
+		}
+		ocounts[freq.Name] = freq.NCousins
+	}
+	var result []enrichment
+	for _, name := range names {
+		e := enrichment{name: name, xmatches: xcounts[name], others: ocounts[name]}
+		if e.xmatches == 0 && e.others == 0 {
+			continue
+		}
+		e.ratio = math.Inf(1)
+		if e.others > 0 {
+			e.ratio = (float64(e.xmatches) / float64(nx)) / (float64(e.others) / float64(no))
+		}
+		e.p = fisherExact(e.xmatches, nx-e.xmatches, e.others, no-e.others)
+		result = append(result, e)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].p != result[j].p {
+			return result[i].p < result[j].p
+		}
+		return result[i].ratio > result[j].ratio
+	})
+	return result
+}
+
+// printEnrichments prints all enrichments with at least min
+// X-matches or at least min other cousins.
+func printEnrichments(enrichments []enrichment, nx, no, min int) {
+	fmt.Print("X-matches:  Others:  Enrichment:  p-value:  Name:\r\n")
+	for _, e := range enrichments {
+		if e.xmatches < min && e.others < min {
+			continue
+		}
+		ratio := "inf"
+		if !math.IsInf(e.ratio, 1) {
+			ratio = fmt.Sprintf("%.2f", e.ratio)
+		}
+		fmt.Printf("%d (%.0f%%)  %d (%.0f%%)  %v  %.3g  %v\r\n",
+			e.xmatches, 100*float64(e.xmatches)/float64(nx),
+			e.others, 100*float64(e.others)/float64(no),
+			ratio, e.p, e.name)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/yogischogi/familyties/cousins"
+)
+
+func TestCompareFrequencies(t *testing.T) {
+	xfreqs := cousins.Frequencies{{NCousins: 8, Name: "Germany"}, {NCousins: 2, Name: "Ireland"}}
+	ofreqs := cousins.Frequencies{{NCousins: 10, Name: "Germany"}, {NCousins: 20, Name: "Ireland"}, {NCousins: 30, Name: "USA"}}
+	enrichments := compareFrequencies(xfreqs, ofreqs, 10, 60)
+
+	byName := make(map[string]enrichment)
+	for _, e := range enrichments {
+		byName[e.name] = e
+	}
+	if len(byName) != 3 {
+		t.Fatalf("enrichments = %v, want Germany, Ireland and USA", enrichments)
+	}
+	germany, ireland, usa := byName["Germany"], byName["Ireland"], byName["USA"]
+	if germany.xmatches != 8 || germany.others != 10 || math.Abs(germany.ratio-4.8) > 1e-9 {
+		t.Errorf("Germany = %+v, want 8 X-matches, 10 others and ratio 4.8", germany)
+	}
+	if ireland.ratio >= 1 {
+		t.Errorf("Ireland = %+v, want ratio below 1", ireland)
+	}
+	// Countries without X-matches are depleted.
+	if usa.xmatches != 0 || usa.others != 30 || usa.ratio != 0 || usa.p >= 0.05 {
+		t.Errorf("USA = %+v, want 0 X-matches, 30 others, ratio 0 and a significant p-value", usa)
+	}
+	for i := 1; i < len(enrichments); i++ {
+		if enrichments[i-1].p > enrichments[i].p {
+			t.Errorf("enrichments are not ordered by p-value: %v", enrichments)
+		}
+	}
+}